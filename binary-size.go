@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// methods used to measure the size of a binary
+const (
+	sizeMethodElfSegments     = "PT_LOAD PF_X"
+	sizeMethodMachOSegment    = "__TEXT"
+	sizeMethodDyldSharedCache = "dyld shared cache __TEXT"
+	sizeMethodFileSize        = "file size"
+	sizeMethodCodeFiles       = "code files"
+)
+
+// files with the code of interpreted applications, counted when the code of a directory is measured
+var codeFileExtensions = map[string]bool{
+	".py": true, ".js": true, ".mjs": true, ".cjs": true, ".rb": true, ".pl": true, ".pm": true, ".jar": true, ".class": true,
+}
+
+// stops measuring directories with more files, e.g. a script run from a home directory
+const maxCodeDirectoryFiles = 100000
+
+// returns the size of the executable code of a binary and how it was measured, binaries of
+// unsupported formats are counted with their full file size
+func getCodeSize(fsys FileSystem, path string) (int64, string, error) {
+	if fileInfo, err := fsys.Stat(path); err == nil && fileInfo.IsDir() {
+		// code directories of interpreted applications, e.g. site-packages
+		size, err := getCodeDirectorySize(fsys, path)
+		return size, sizeMethodCodeFiles, err
+	}
+
+	file, err := fsys.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer file.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(file, magic); err == nil {
+		switch binary.BigEndian.Uint32(magic) {
+		case 0xfeedface, 0xfeedfacf, 0xcefaedfe, 0xcffaedfe:
+			size, err := getMachOCodeSize(file)
+			return size, sizeMethodMachOSegment, err
+		case 0xcafebabe:
+			// Java class files share the magic number with universal binaries
+			if size, err := getMachOCodeSize(file); err == nil {
+				return size, sizeMethodMachOSegment, nil
+			}
+		default:
+			if bytes.Equal(magic, []byte(elf.ELFMAG)) {
+				size, err := getElfCodeSize(file)
+				return size, sizeMethodElfSegments, err
+			}
+		}
+	}
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return 0, "", err
+	}
+	return fileInfo.Size(), sizeMethodFileSize, nil
+}
+
+// returns the sum of all loadable ELF segments that are mapped executable
+// note: we deliberately ignore data segments, debug info and symbol tables as they are static
+// artifacts and are not at risk of being penetrated, this matches the __TEXT segment size on darwin
+func getElfCodeSize(reader io.ReaderAt) (int64, error) {
+	elfFile, err := elf.NewFile(reader)
+	if err != nil {
+		return 0, err
+	}
+	defer elfFile.Close()
+
+	var size int64
+	for _, prog := range elfFile.Progs {
+		if prog.Type == elf.PT_LOAD && prog.Flags&elf.PF_X != 0 {
+			size += int64(prog.Memsz)
+		}
+	}
+	return size, nil
+}
+
+// returns the size of the __TEXT segment of a Mach-O file, which contains the executable code,
+// universal binaries (fat files) are sized by the slice matching our architecture
+func getMachOCodeSize(reader io.ReaderAt) (int64, error) {
+	var machoFile *macho.File
+	fatFile, err := macho.NewFatFile(reader)
+	switch err {
+	case nil:
+		defer fatFile.Close()
+		machoFile = selectMachOArchitecture(fatFile)
+	case macho.ErrNotFat:
+		machoFile, err = macho.NewFile(reader)
+		if err != nil {
+			return 0, err
+		}
+		defer machoFile.Close()
+	default:
+		return 0, err
+	}
+
+	textSegment := machoFile.Segment("__TEXT")
+	if textSegment == nil {
+		return 0, fmt.Errorf("Mach-O file has no __TEXT segment")
+	}
+	return int64(textSegment.Memsz), nil
+}
+
+// returns the size of a file on disk, the size of the code files for directories
+func getFileSize(fsys FileSystem, path string) (int64, error) {
+	fileInfo, err := fsys.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	if fileInfo.IsDir() {
+		return getCodeDirectorySize(fsys, path)
+	}
+	return fileInfo.Size(), nil
+}
+
+// returns the size of the code files of a directory tree, hidden directories like .git and symlinks
+// are skipped, package directories are skipped as well as they are measured on their own unless the
+// directory is one, e.g. nested node_modules
+func getCodeDirectorySize(fsys FileSystem, directory string) (int64, error) {
+	var size int64
+	files := 0
+	directories := []string{directory}
+	for len(directories) > 0 {
+		current := directories[0]
+		directories = directories[1:]
+		entries, err := fsys.ReadDir(current)
+		if err != nil {
+			if current == directory {
+				return 0, err
+			}
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			switch {
+			case entry.IsDir():
+				isPackageDirectory := name == "node_modules" || name == "site-packages" || name == "dist-packages"
+				if strings.HasPrefix(name, ".") || (isPackageDirectory && name != filepath.Base(directory)) {
+					continue
+				}
+				directories = append(directories, filepath.Join(current, name))
+			case entry.Type().IsRegular() && codeFileExtensions[strings.ToLower(filepath.Ext(name))]:
+				files++
+				if files > maxCodeDirectoryFiles {
+					fmt.Fprintf(os.Stderr, "WARNING: stopped measuring %s after %d code files\n", directory, maxCodeDirectoryFiles)
+					return size, nil
+				}
+				if fileInfo, err := entry.Info(); err == nil {
+					size += fileInfo.Size()
+				}
+			}
+		}
+	}
+	return size, nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestGetCodeSizeMachO(t *testing.T) {
+	// universal binaries are sized by the slice of our architecture, the first one otherwise
+	universalTextSize := int64(4096)
+	if runtime.GOARCH == "arm64" {
+		universalTextSize = 16384
+	}
+	tests := []struct {
+		path     string
+		expected int64
+	}{
+		{"/Hello.app/Contents/MacOS/Hello", universalTextSize},
+		{"/Hello.app/Contents/Frameworks/libutil.dylib", 4096},
+		{"/Hello.app/Contents/Frameworks/Greeting.framework/Greeting", 4096},
+	}
+	fsys := rootFileSystem{root: "testdata/macho"}
+	for _, test := range tests {
+		size, sizeMethod, err := getCodeSize(fsys, test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		if size != test.expected || sizeMethod != sizeMethodMachOSegment {
+			t.Errorf("%s: got %d bytes by %s, expected %d bytes by %s", test.path, size, sizeMethod, test.expected, sizeMethodMachOSegment)
+		}
+	}
+}
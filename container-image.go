@@ -0,0 +1,469 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// OCI media types of image indexes and manifests, Docker's media types are handled the same way
+const (
+	mediaTypeOciIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// imageArchive gives access to the files of an OCI image layout or a `docker save` tarball,
+// which is either an extracted directory or a tar file
+type imageArchive interface {
+	Open(name string) (io.ReadCloser, error)
+	Close() error
+}
+
+// directoryImageArchive is an extracted OCI image layout or `docker save` tarball
+type directoryImageArchive struct {
+	directory string
+}
+
+func (a directoryImageArchive) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(a.directory, filepath.Clean("/"+name)))
+}
+
+func (a directoryImageArchive) Close() error {
+	return nil
+}
+
+// tarImageArchive is an OCI image layout or `docker save` tarball, its files are read in place
+type tarImageArchive struct {
+	file    *os.File
+	entries map[string]*io.SectionReader // by cleaned name
+}
+
+// indexes the files of an uncompressed tarball, so they can be read without extracting them
+func openTarImageArchive(path string) (*tarImageArchive, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	archive := &tarImageArchive{file: file, entries: map[string]*io.SectionReader{}}
+
+	// tar.Reader reads the headers block by block, so the position of the underlying reader is the
+	// start of the content after each header
+	counter := &countingReader{reader: file}
+	tarReader := tar.NewReader(counter)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read tarball %s: %v", path, err)
+		}
+		if header.Typeflag == tar.TypeReg {
+			name := filepath.Clean("/" + header.Name)
+			archive.entries[name] = io.NewSectionReader(file, counter.count, header.Size)
+		}
+	}
+	return archive, nil
+}
+
+func (a *tarImageArchive) Open(name string) (io.ReadCloser, error) {
+	entry, ok := a.entries[filepath.Clean("/"+name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return sectionReadCloser{io.NewSectionReader(entry, 0, entry.Size())}, nil
+}
+
+func (a *tarImageArchive) Close() error {
+	return a.file.Close()
+}
+
+// sectionReadCloser is a file of a tarball, like an *os.File it can be read at any offset
+type sectionReadCloser struct {
+	*io.SectionReader
+}
+
+func (sectionReadCloser) Close() error {
+	return nil
+}
+
+type countingReader struct {
+	reader io.Reader
+	count  int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.count += int64(n)
+	return n, err
+}
+
+// ociDescriptor references a blob of an OCI image layout by its digest
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    *struct {
+		Architecture string `json:"architecture"`
+		OS           string `json:"os"`
+	} `json:"platform"`
+}
+
+// ociIndex is the index.json of an OCI image layout or a nested image index
+type ociIndex struct {
+	MediaType string          `json:"mediaType"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// ociManifest lists the config and the layers of an image
+type ociManifest struct {
+	Config ociDescriptor   `json:"config"`
+	Layers []ociDescriptor `json:"layers"`
+}
+
+// dockerManifest is an entry of the manifest.json of a `docker save` tarball
+type dockerManifest struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// imageConfig holds the parts of the image configuration that define how the container is started
+type imageConfig struct {
+	Config struct {
+		User       string   `json:"User"`
+		Env        []string `json:"Env"`
+		Entrypoint []string `json:"Entrypoint"`
+		Cmd        []string `json:"Cmd"`
+		WorkingDir string   `json:"WorkingDir"`
+	} `json:"config"`
+}
+
+// containerImage is an image with its layers applied
+type containerImage struct {
+	name       string
+	config     imageConfig
+	fileSystem *imageFileSystem
+}
+
+// analyses the ENTRYPOINT/CMD binary of an OCI image layout or a `docker save` tarball and the
+// libraries it links against, without a container runtime or network access
+func scanContainerImage(imagePath string, riskWeights RiskWeights) ([]ProcessInfo, error) {
+	fileInfo, err := os.Stat(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan image: %v", err)
+	}
+	var archive imageArchive
+	if fileInfo.IsDir() {
+		archive = directoryImageArchive{directory: imagePath}
+	} else {
+		archive, err = openTarImageArchive(imagePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan image: %v", err)
+		}
+	}
+	defer archive.Close()
+
+	image, err := openContainerImage(archive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan image %s: %v", imagePath, err)
+	}
+	defer image.fileSystem.Close()
+
+	arguments := append(append([]string{}, image.config.Config.Entrypoint...), image.config.Config.Cmd...)
+	if len(image.config.Config.Entrypoint) == 0 {
+		arguments = image.config.Config.Cmd
+	}
+	if len(arguments) == 0 {
+		return nil, fmt.Errorf("failed to scan image %s: image has neither ENTRYPOINT nor CMD", imagePath)
+	}
+	executablePath, arguments, err := resolveImageCommand(image, arguments)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan image %s: %v", imagePath, err)
+	}
+	fmt.Fprintf(os.Stderr, "Analysing %s of image %s...\n", executablePath, image.name)
+
+	// interpreters and runtimes like python3 app.py are analysed by the application they run
+	workingDirectory := filepath.Join("/", image.config.Config.WorkingDir)
+	info := analyseBinary(image.fileSystem, executablePath, arguments, workingDirectory, image.config.Config.Env)
+	info.UserId = getImageUserId(image)
+	info.Privilege = classifyPrivilege(info)
+	info.RiskScore = calculateRiskScore(info, riskWeights)
+	return []ProcessInfo{info}, nil
+}
+
+// reads the manifest and the config of an image and applies its layers
+func openContainerImage(archive imageArchive) (containerImage, error) {
+	image := containerImage{}
+	var configName string
+	var layerNames []string
+
+	// `docker save` tarballs have a manifest.json, as of Docker 25 they are OCI image layouts as well
+	if data, err := readArchiveFile(archive, "manifest.json"); err == nil {
+		var manifests []dockerManifest
+		if err := json.Unmarshal(data, &manifests); err != nil {
+			return image, fmt.Errorf("failed to parse manifest.json: %v", err)
+		}
+		if len(manifests) == 0 {
+			return image, fmt.Errorf("manifest.json lists no images")
+		}
+		if len(manifests) > 1 {
+			fmt.Fprintf(os.Stderr, "WARNING: tarball contains %d images, only the first one is analysed\n", len(manifests))
+		}
+		configName = manifests[0].Config
+		layerNames = manifests[0].Layers
+		if len(manifests[0].RepoTags) > 0 {
+			image.name = manifests[0].RepoTags[0]
+		}
+	} else {
+		data, err := readArchiveFile(archive, "index.json")
+		if err != nil {
+			return image, fmt.Errorf("neither manifest.json nor index.json found, not an OCI image layout or docker save tarball")
+		}
+		var index ociIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			return image, fmt.Errorf("failed to parse index.json: %v", err)
+		}
+		manifest, name, err := selectOciManifest(archive, index)
+		if err != nil {
+			return image, err
+		}
+		image.name = name
+		configName = ociBlobName(manifest.Config.Digest)
+		for _, layer := range manifest.Layers {
+			layerNames = append(layerNames, ociBlobName(layer.Digest))
+		}
+	}
+	if image.name == "" {
+		image.name = "<untagged>"
+	}
+
+	data, err := readArchiveFile(archive, configName)
+	if err != nil {
+		return image, fmt.Errorf("failed to read image config: %v", err)
+	}
+	if err := json.Unmarshal(data, &image.config); err != nil {
+		return image, fmt.Errorf("failed to parse image config: %v", err)
+	}
+
+	var layers []func() (io.ReadCloser, error)
+	for _, layerName := range layerNames {
+		layers = append(layers, func() (io.ReadCloser, error) {
+			return archive.Open(layerName)
+		})
+	}
+	image.fileSystem, err = newImageFileSystem(layers)
+	return image, err
+}
+
+// returns the image manifest of an index, multi-platform images are resolved to the platform we
+// run on or linux/amd64
+func selectOciManifest(archive imageArchive, index ociIndex) (ociManifest, string, error) {
+	var manifest ociManifest
+	var name string
+	for depth := 0; depth < 8; depth++ {
+		if len(index.Manifests) == 0 {
+			return manifest, name, fmt.Errorf("image index lists no manifests")
+		}
+		descriptor := index.Manifests[0]
+		for _, candidate := range index.Manifests {
+			if candidate.Platform == nil {
+				continue
+			}
+			if candidate.Platform.OS == "linux" && candidate.Platform.Architecture == runtime.GOARCH {
+				descriptor = candidate
+				break
+			}
+			if candidate.Platform.OS == "linux" && candidate.Platform.Architecture == "amd64" {
+				descriptor = candidate
+			}
+		}
+		if refName := descriptor.Annotations["org.opencontainers.image.ref.name"]; refName != "" && name == "" {
+			name = refName
+		}
+
+		data, err := readArchiveFile(archive, ociBlobName(descriptor.Digest))
+		if err != nil {
+			return manifest, name, fmt.Errorf("failed to read manifest %s: %v", descriptor.Digest, err)
+		}
+		if descriptor.MediaType == mediaTypeOciIndex || descriptor.MediaType == mediaTypeDockerManifest {
+			index = ociIndex{}
+			if err := json.Unmarshal(data, &index); err != nil {
+				return manifest, name, fmt.Errorf("failed to parse image index %s: %v", descriptor.Digest, err)
+			}
+			continue
+		}
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return manifest, name, fmt.Errorf("failed to parse manifest %s: %v", descriptor.Digest, err)
+		}
+		return manifest, name, nil
+	}
+	return manifest, name, fmt.Errorf("image indexes are nested too deep")
+}
+
+// returns the path of a blob in an OCI image layout, e.g. blobs/sha256/<hex> for sha256:<hex>
+func ociBlobName(digest string) string {
+	algorithm, encoded, _ := strings.Cut(digest, ":")
+	return filepath.Join("blobs", algorithm, encoded)
+}
+
+func readArchiveFile(archive imageArchive, name string) ([]byte, error) {
+	reader, err := archive.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// finds the executable of the container command the way the container runtime does, commands
+// without a slash are searched in the PATH of the image environment
+func findImageExecutable(image containerImage, command string) (string, error) {
+	if strings.Contains(command, "/") {
+		path := command
+		if !filepath.IsAbs(path) {
+			path = filepath.Join("/", image.config.Config.WorkingDir, path)
+		}
+		if _, err := image.fileSystem.Stat(path); err != nil {
+			return "", fmt.Errorf("executable %s not found in image", command)
+		}
+		return path, nil
+	}
+
+	searchPath := "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	for _, variable := range image.config.Config.Env {
+		if value, found := strings.CutPrefix(variable, "PATH="); found {
+			searchPath = value
+		}
+	}
+	for _, directory := range strings.Split(searchPath, ":") {
+		path := filepath.Join("/", directory, command)
+		if fileInfo, err := image.fileSystem.Stat(path); err == nil && fileInfo.Mode().IsRegular() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("executable %s not found in PATH of image", command)
+}
+
+// returns the executable and the command line of the process a container ends up running, scripts
+// are followed to the interpreter of their shebang like the kernel does, and entrypoint scripts of
+// shells to the CMD they exec, e.g. nginx for `/docker-entrypoint.sh nginx -g "daemon off;"`
+func resolveImageCommand(image containerImage, arguments []string) (string, []string, error) {
+	for depth := 0; depth < 8; depth++ {
+		executablePath, err := findImageExecutable(image, arguments[0])
+		if err != nil {
+			return "", nil, err
+		}
+		interpreterArguments, script := readImageScript(image.fileSystem, executablePath)
+		if interpreterArguments == nil {
+			return executablePath, arguments, nil
+		}
+		if imageShells[filepath.Base(interpreterArguments[0])] && len(arguments) > 1 && execArgumentsPattern.Match(script) &&
+			isImageExecutable(image, arguments[1]) {
+			fmt.Fprintf(os.Stderr, "following entrypoint script %s to %s...\n", executablePath, arguments[1])
+			arguments = arguments[1:]
+			continue
+		}
+		// the kernel runs the interpreter with the path of the script, e.g. python3 /app/main.py
+		arguments = append(append(interpreterArguments, executablePath), arguments[1:]...)
+	}
+	return "", nil, fmt.Errorf("scripts of %s are nested too deep", arguments[0])
+}
+
+// reports if a command exists in an image, entrypoint scripts that get something else than a command
+// passed handle it themselves
+func isImageExecutable(image containerImage, command string) bool {
+	_, err := findImageExecutable(image, command)
+	return err == nil
+}
+
+// shells that run entrypoint scripts, these end with `exec "$@"` by convention, so the container
+// runs the CMD that is passed to the script and not the shell
+var imageShells = map[string]bool{"sh": true, "ash": true, "bash": true, "dash": true, "zsh": true}
+var execArgumentsPattern = regexp.MustCompile(`(?m)^[^#\n]*\bexec\b[^\n]*"\$@"`)
+
+// maximum size of a script read to find out what it executes
+const maxImageScriptSize = 1 << 20
+
+// returns the interpreter of a script with its argument from the shebang line, e.g. [/bin/sh -e] for
+// #!/bin/sh -e, and the script itself, /usr/bin/env is replaced by the command it runs, the
+// interpreter is nil for binaries
+func readImageScript(fsys FileSystem, path string) ([]string, []byte) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, nil
+	}
+	defer file.Close()
+	magic := make([]byte, 2)
+	if _, err := file.ReadAt(magic, 0); err != nil || string(magic) != "#!" {
+		return nil, nil
+	}
+	script, err := io.ReadAll(io.LimitReader(file, maxImageScriptSize))
+	if err != nil {
+		return nil, nil
+	}
+
+	// the kernel passes everything after the interpreter as a single argument
+	line, _, _ := bytes.Cut(script[2:], []byte("\n"))
+	interpreter, argument, _ := strings.Cut(strings.TrimSpace(strings.ReplaceAll(string(line), "\t", " ")), " ")
+	argument = strings.TrimSpace(argument)
+	if filepath.Base(interpreter) == "env" {
+		// e.g. #!/usr/bin/env python3 or #!/usr/bin/env -S python3 -u
+		var command []string
+		for _, field := range strings.Fields(argument) {
+			if len(command) == 0 && (strings.HasPrefix(field, "-") || strings.Contains(field, "=")) {
+				continue
+			}
+			command = append(command, field)
+		}
+		if len(command) == 0 {
+			return nil, nil
+		}
+		return command, script
+	}
+	if interpreter == "" {
+		return nil, nil
+	}
+	if argument == "" {
+		return []string{interpreter}, script
+	}
+	return []string{interpreter, argument}, script
+}
+
+// returns the user id the container runs as, containers run as root unless the image sets a user
+func getImageUserId(image containerImage) int {
+	user, _, _ := strings.Cut(image.config.Config.User, ":")
+	if user == "" {
+		return 0
+	}
+	if uid, err := strconv.Atoi(user); err == nil {
+		return uid
+	}
+
+	// user names are resolved with the /etc/passwd of the image
+	data, err := readFile(image.fileSystem, "/etc/passwd")
+	if err != nil {
+		return -1
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 || fields[0] != user {
+			continue
+		}
+		if uid, err := strconv.Atoi(fields[2]); err == nil {
+			return uid
+		}
+	}
+	return -1
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestResolveImageCommand(t *testing.T) {
+	layer := buildTestLayer(t, []testTarEntry{
+		{name: "bin/busybox", content: "\x7fELF"},
+		{name: "bin/sh", linkname: "busybox", typeflag: tar.TypeSymlink},
+		{name: "bin/bash", content: "\x7fELF"},
+		{name: "usr/bin/env", content: "\x7fELF"},
+		{name: "usr/sbin/nginx", content: "\x7fELF"},
+		{name: "usr/local/bin/python3", content: "\x7fELF"},
+		{name: "docker-entrypoint.sh", content: "#!/bin/sh\nset -e\n# runs the CMD\nif [ \"$1\" = nginx ]; then\n\techo starting\nfi\nexec \"$@\"\n"},
+		{name: "commented-entrypoint.sh", content: "#!/bin/sh\n# exec \"$@\" is not needed\nnginx\n"},
+		{name: "run.sh", content: "#!/bin/bash -e\nnginx -g 'daemon off;'\n"},
+		{name: "app/main.py", content: "#!/usr/bin/env python3\nprint('hello')\n"},
+		{name: "app/worker.py", content: "#!/usr/bin/env -S PYTHONUNBUFFERED=1 python3 -u\nprint('hello')\n"},
+	}, false)
+	fsys, err := newImageFileSystem([]func() (io.ReadCloser, error){
+		func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(layer)), nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+	image := containerImage{fileSystem: fsys}
+	image.config.Config.Env = []string{"PATH=/usr/local/bin:/usr/sbin:/usr/bin:/bin"}
+	image.config.Config.WorkingDir = "/app"
+
+	tests := []struct {
+		arguments          []string
+		expectedExecutable string
+		expectedArguments  []string
+	}{
+		{[]string{"/docker-entrypoint.sh", "nginx", "-g", "daemon off;"}, "/usr/sbin/nginx", []string{"nginx", "-g", "daemon off;"}},
+		{[]string{"/docker-entrypoint.sh"}, "/bin/sh", []string{"/bin/sh", "/docker-entrypoint.sh"}},
+		{[]string{"/docker-entrypoint.sh", "main.py"}, "/bin/sh", []string{"/bin/sh", "/docker-entrypoint.sh", "main.py"}},
+		{[]string{"/docker-entrypoint.sh", "./main.py", "--port", "80"}, "/usr/local/bin/python3", []string{"python3", "/app/main.py", "--port", "80"}},
+		{[]string{"/commented-entrypoint.sh", "nginx"}, "/bin/sh", []string{"/bin/sh", "/commented-entrypoint.sh", "nginx"}},
+		{[]string{"/run.sh", "x"}, "/bin/bash", []string{"/bin/bash", "-e", "/run.sh", "x"}},
+		{[]string{"worker.py"}, "", nil},
+		{[]string{"./worker.py"}, "/usr/local/bin/python3", []string{"python3", "-u", "/app/worker.py"}},
+		{[]string{"nginx"}, "/usr/sbin/nginx", []string{"nginx"}},
+	}
+	for _, test := range tests {
+		executable, arguments, err := resolveImageCommand(image, test.arguments)
+		if test.expectedExecutable == "" {
+			if err == nil {
+				t.Errorf("%v: no error", test.arguments)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: %v", test.arguments, err)
+			continue
+		}
+		if executable != test.expectedExecutable || !reflect.DeepEqual(arguments, test.expectedArguments) {
+			t.Errorf("%v: got %s %q, expected %s %q", test.arguments, executable, arguments, test.expectedExecutable, test.expectedArguments)
+		}
+	}
+}
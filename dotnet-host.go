@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	. "github.com/ahmetb/go-linq" // LINQ for Go to manage data structure like its 2025
+)
+
+// options of the dotnet host and mono that are followed by a value, e.g. dotnet exec --depsfile app.deps.json app.dll
+var dotNetHostOptionsWithValue = map[string]bool{
+	"--additionalprobingpath":           true,
+	"--additional-deps":                 true,
+	"--depsfile":                        true,
+	"--fx-version":                      true,
+	"--roll-forward":                    true,
+	"--roll-forward-on-no-candidate-fx": true,
+	"--runtimeconfig":                   true,
+	"--config":                          true, // mono
+}
+
+// dependencies of a .NET application, see https://github.com/dotnet/sdk/blob/main/documentation/specs/runtime-configuration-file.md
+type dotNetDepsFile struct {
+	RuntimeTarget struct {
+		Name string `json:"name"`
+	} `json:"runtimeTarget"`
+	Targets map[string]map[string]struct {
+		Runtime map[string]json.RawMessage `json:"runtime"` // managed assemblies by their path in the package
+	} `json:"targets"`
+	Libraries map[string]struct {
+		Path string `json:"path"` // directory of a package in the NuGet cache
+	} `json:"libraries"`
+}
+
+// returns the entry assembly of a dotnet or mono command line and the deps.json file given with
+// --depsfile, e.g. bin/app.dll of `dotnet exec --depsfile app.deps.json bin/app.dll --urls http://*:80`,
+// the assembly is empty for commands of the .NET SDK like `dotnet build`
+func getDotNetEntryAssembly(args []string) (string, string) {
+	depsFile := ""
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case i == 1 && arg == "exec":
+		case dotNetHostOptionsWithValue[arg]:
+			if arg == "--depsfile" && i+1 < len(args) {
+				depsFile = args[i+1]
+			}
+			i++
+		case strings.HasPrefix(arg, "-"):
+			// flags of the host or of mono, e.g. --debug or --gc=sgen
+		default:
+			// the first argument that is not an option is the application, its arguments follow
+			extension := strings.ToLower(filepath.Ext(arg))
+			if extension == ".dll" || extension == ".exe" {
+				return arg, depsFile
+			}
+			return "", depsFile
+		}
+	}
+	return "", depsFile
+}
+
+// analyses the entry assembly of a process of the dotnet or mono runtime, see ApplicationPath, instead
+// of the runtime itself and returns its code, the assembly and the managed assemblies it depends on
+// according to its deps.json
+func analyseDotNetApplication(fsys FileSystem, info *ProcessInfo, args []string, cwd string, environment []string) []LibraryInfo {
+	assembly := info.ApplicationPath
+	evidence := fmt.Sprintf("Found .NET assembly %s in commandline of %s", assembly, info.Name)
+
+	languageInfo, err := DetectSourceLanguageFromFileSystem(fsys, assembly)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not analyse .NET assembly: %v\n", err)
+		languageInfo = BinaryLanguageInfo{}
+		languageInfo.addEvidence(evidenceCommandLine, evidence, ".NET")
+	} else {
+		// the runtime runs an assembly of one of the .NET languages, its metadata tells which
+		languageInfo.addEvidence(evidenceCommandLine, evidence, "C#", "VB.NET", "F#")
+	}
+	languageInfo = determineMostLikelyLanguage(languageInfo)
+	info.DetectedLanguage = languageInfo.MostLikelyLanguage
+	info.LanguageConfidence = languageInfo.Confidence
+	info.LanguageEvidence = languageInfo.Evidence
+	info.WeightedLanguageEvidence = languageInfo.WeightedEvidence
+	info.DotNetAssembly = languageInfo.DotNetAssembly
+	if err != nil {
+		return nil
+	}
+
+	// the application is code of the process as well
+	code := []LibraryInfo{{Path: assembly, Origin: "application"}}
+
+	// app.dll is published next to its app.deps.json
+	_, depsFile := getDotNetEntryAssembly(args)
+	if depsFile == "" {
+		depsFile = strings.TrimSuffix(assembly, filepath.Ext(assembly)) + ".deps.json"
+	} else {
+		depsFile = resolveProcessPath(depsFile, cwd)
+	}
+	dependencies, err := getDotNetDependencies(fsys, depsFile, environment)
+	if err != nil {
+		// e.g. mono applications or assemblies built without deps.json
+		fmt.Fprintf(os.Stderr, "WARNING: could not get .NET dependencies: %v\n", err)
+		return code
+	}
+	for _, dependency := range dependencies {
+		if dependency != assembly {
+			code = append(code, LibraryInfo{Path: dependency, Origin: "managed"})
+		}
+	}
+	return code
+}
+
+// returns the paths of the managed assemblies listed in a deps.json file, they are looked up next to
+// the deps.json file like published applications have them, and in the NuGet cache for applications
+// that are run from their build output
+func getDotNetDependencies(fsys FileSystem, depsFile string, environment []string) ([]string, error) {
+	file, err := fsys.Open(depsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deps.json: %v", err)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read deps.json: %v", err)
+	}
+	var deps dotNetDepsFile
+	if err := json.Unmarshal(data, &deps); err != nil {
+		return nil, fmt.Errorf("failed to parse deps.json %s: %v", depsFile, err)
+	}
+
+	var packagesDirectory string
+	for _, variable := range environment {
+		if value, found := strings.CutPrefix(variable, "HOME="); found && packagesDirectory == "" {
+			packagesDirectory = filepath.Join(value, ".nuget", "packages")
+		}
+		if value, found := strings.CutPrefix(variable, "NUGET_PACKAGES="); found {
+			packagesDirectory = value
+		}
+	}
+
+	// the runtime target is the only target of published applications
+	targets := []string{deps.RuntimeTarget.Name}
+	if _, found := deps.Targets[deps.RuntimeTarget.Name]; !found {
+		From(deps.Targets).
+			SelectT(func(target KeyValue) string {
+				return target.Key.(string)
+			}).
+			OrderByT(func(target string) string {
+				return target
+			}).
+			ToSlice(&targets)
+	}
+
+	applicationDirectory := filepath.Dir(depsFile)
+	var assemblies []string
+	for _, target := range targets {
+		for library, dependency := range deps.Targets[target] {
+			for asset := range dependency.Runtime {
+				candidates := []string{filepath.Join(applicationDirectory, path.Base(asset))}
+				if libraryInfo := deps.Libraries[library]; libraryInfo.Path != "" && packagesDirectory != "" {
+					candidates = append(candidates, filepath.Join(packagesDirectory, libraryInfo.Path, asset))
+				}
+				resolved := false
+				for _, candidate := range candidates {
+					if _, err := fsys.Stat(candidate); err == nil {
+						assemblies = append(assemblies, candidate)
+						resolved = true
+						break
+					}
+				}
+				if !resolved {
+					fmt.Fprintf(os.Stderr, "WARNING: could not resolve assembly %s of %s\n", asset, library)
+				}
+			}
+		}
+	}
+
+	From(assemblies).
+		Distinct().
+		OrderByT(func(assembly string) string {
+			return assembly
+		}).
+		ToSlice(&assemblies)
+	return assemblies, nil
+}
+
+// resolves a path of a command line relative to the working directory of the process
+func resolveProcessPath(commandLinePath string, cwd string) string {
+	if filepath.IsAbs(commandLinePath) || cwd == "" {
+		return filepath.Clean(commandLinePath)
+	}
+	return filepath.Join(cwd, commandLinePath)
+}
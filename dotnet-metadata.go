@@ -0,0 +1,565 @@
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"strings"
+)
+
+// DotNetAssembly holds the CLI metadata of a .NET assembly
+type DotNetAssembly struct {
+	Name            string                    `json:"name"`
+	Version         string                    `json:"version"`
+	RuntimeVersion  string                    `json:"runtime_version"`  // metadata version, e.g. v4.0.30319
+	TargetFramework string                    `json:"target_framework"` // e.g. .NETCoreApp,Version=v8.0, empty if unknown
+	ILOnly          bool                      `json:"il_only"`          // false for mixed-mode assemblies with native code, e.g. C++/CLI, and ReadyToRun images
+	ReadyToRun      bool                      `json:"ready_to_run"`     // precompiled from IL to native code by crossgen
+	References      []DotNetAssemblyReference `json:"references"`
+}
+
+// DotNetAssemblyReference is an assembly referenced by a .NET assembly
+type DotNetAssemblyReference struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// metadata tables we read, see ECMA-335 II.22
+const (
+	tableModule                 = 0x00
+	tableTypeRef                = 0x01
+	tableTypeDef                = 0x02
+	tableFieldPtr               = 0x03
+	tableField                  = 0x04
+	tableMethodPtr              = 0x05
+	tableMethodDef              = 0x06
+	tableParamPtr               = 0x07
+	tableParam                  = 0x08
+	tableInterfaceImpl          = 0x09
+	tableMemberRef              = 0x0a
+	tableConstant               = 0x0b
+	tableCustomAttribute        = 0x0c
+	tableFieldMarshal           = 0x0d
+	tableDeclSecurity           = 0x0e
+	tableClassLayout            = 0x0f
+	tableFieldLayout            = 0x10
+	tableStandAloneSig          = 0x11
+	tableEventMap               = 0x12
+	tableEventPtr               = 0x13
+	tableEvent                  = 0x14
+	tablePropertyMap            = 0x15
+	tablePropertyPtr            = 0x16
+	tableProperty               = 0x17
+	tableMethodSemantics        = 0x18
+	tableMethodImpl             = 0x19
+	tableModuleRef              = 0x1a
+	tableTypeSpec               = 0x1b
+	tableImplMap                = 0x1c
+	tableFieldRva               = 0x1d
+	tableEncLog                 = 0x1e
+	tableEncMap                 = 0x1f
+	tableAssembly               = 0x20
+	tableAssemblyProcessor      = 0x21
+	tableAssemblyOs             = 0x22
+	tableAssemblyRef            = 0x23
+	tableAssemblyRefProcessor   = 0x24
+	tableAssemblyRefOs          = 0x25
+	tableFile                   = 0x26
+	tableExportedType           = 0x27
+	tableManifestResource       = 0x28
+	tableNestedClass            = 0x29
+	tableGenericParam           = 0x2a
+	tableMethodSpec             = 0x2b
+	tableGenericParamConstraint = 0x2c
+	tableCount                  = 0x2d
+)
+
+// coded indexes reference one of several tables, the low bits select the table, see ECMA-335 II.24.2.6
+var (
+	codedTypeDefOrRef        = []int{tableTypeDef, tableTypeRef, tableTypeSpec}
+	codedHasConstant         = []int{tableField, tableParam, tableProperty}
+	codedHasCustomAttribute  = []int{tableMethodDef, tableField, tableTypeRef, tableTypeDef, tableParam, tableInterfaceImpl, tableMemberRef, tableModule, tableDeclSecurity, tableProperty, tableEvent, tableStandAloneSig, tableModuleRef, tableTypeSpec, tableAssembly, tableAssemblyRef, tableFile, tableExportedType, tableManifestResource, tableGenericParam, tableGenericParamConstraint, tableMethodSpec}
+	codedHasFieldMarshal     = []int{tableField, tableParam}
+	codedHasDeclSecurity     = []int{tableTypeDef, tableMethodDef, tableAssembly}
+	codedMemberRefParent     = []int{tableTypeDef, tableTypeRef, tableModuleRef, tableMethodDef, tableTypeSpec}
+	codedHasSemantics        = []int{tableEvent, tableProperty}
+	codedMethodDefOrRef      = []int{tableMethodDef, tableMemberRef}
+	codedMemberForwarded     = []int{tableField, tableMethodDef}
+	codedImplementation      = []int{tableFile, tableAssemblyRef, tableExportedType}
+	codedCustomAttributeType = []int{-1, -1, tableMethodDef, tableMemberRef, -1}
+	codedResolutionScope     = []int{tableModule, tableModuleRef, tableAssemblyRef, tableTypeRef}
+	codedTypeOrMethodDef     = []int{tableTypeDef, tableMethodDef}
+)
+
+// splits a coded index into its table and row number, the table is -1 for tags of no table
+func decodeCodedIndex(coded []int, value uint32) (int, uint32) {
+	tagBits := bits.Len(uint(len(coded) - 1))
+	tag := int(value & (1<<tagBits - 1))
+	if tag >= len(coded) {
+		return -1, 0
+	}
+	return coded[tag], value >> tagBits
+}
+
+// column kinds of the metadata tables: fixed size integers, heap indexes, table indexes and coded indexes
+type metadataColumn struct {
+	size  int   // 2 or 4 for constants
+	heap  byte  // 's'trings, 'g'uid or 'b'lob heap index
+	table int   // index into a table, -1 if none
+	coded []int // coded index
+}
+
+var (
+	columnU16    = metadataColumn{size: 2, table: -1}
+	columnU32    = metadataColumn{size: 4, table: -1}
+	columnString = metadataColumn{heap: 's', table: -1}
+	columnGuid   = metadataColumn{heap: 'g', table: -1}
+	columnBlob   = metadataColumn{heap: 'b', table: -1}
+	columnIndex  = func(table int) metadataColumn { return metadataColumn{table: table} }
+	columnCoded  = func(tables []int) metadataColumn { return metadataColumn{table: -1, coded: tables} }
+)
+
+// columns of all metadata tables, needed to skip the tables before the ones we read
+var metadataTableColumns = [tableCount][]metadataColumn{
+	tableModule:                 {columnU16, columnString, columnGuid, columnGuid, columnGuid},
+	tableTypeRef:                {columnCoded(codedResolutionScope), columnString, columnString},
+	tableTypeDef:                {columnU32, columnString, columnString, columnCoded(codedTypeDefOrRef), columnIndex(tableField), columnIndex(tableMethodDef)},
+	tableFieldPtr:               {columnIndex(tableField)},
+	tableField:                  {columnU16, columnString, columnBlob},
+	tableMethodPtr:              {columnIndex(tableMethodDef)},
+	tableMethodDef:              {columnU32, columnU16, columnU16, columnString, columnBlob, columnIndex(tableParam)},
+	tableParamPtr:               {columnIndex(tableParam)},
+	tableParam:                  {columnU16, columnU16, columnString},
+	tableInterfaceImpl:          {columnIndex(tableTypeDef), columnCoded(codedTypeDefOrRef)},
+	tableMemberRef:              {columnCoded(codedMemberRefParent), columnString, columnBlob},
+	tableConstant:               {columnU16, columnCoded(codedHasConstant), columnBlob},
+	tableCustomAttribute:        {columnCoded(codedHasCustomAttribute), columnCoded(codedCustomAttributeType), columnBlob},
+	tableFieldMarshal:           {columnCoded(codedHasFieldMarshal), columnBlob},
+	tableDeclSecurity:           {columnU16, columnCoded(codedHasDeclSecurity), columnBlob},
+	tableClassLayout:            {columnU16, columnU32, columnIndex(tableTypeDef)},
+	tableFieldLayout:            {columnU32, columnIndex(tableField)},
+	tableStandAloneSig:          {columnBlob},
+	tableEventMap:               {columnIndex(tableTypeDef), columnIndex(tableEvent)},
+	tableEventPtr:               {columnIndex(tableEvent)},
+	tableEvent:                  {columnU16, columnString, columnCoded(codedTypeDefOrRef)},
+	tablePropertyMap:            {columnIndex(tableTypeDef), columnIndex(tableProperty)},
+	tablePropertyPtr:            {columnIndex(tableProperty)},
+	tableProperty:               {columnU16, columnString, columnBlob},
+	tableMethodSemantics:        {columnU16, columnIndex(tableMethodDef), columnCoded(codedHasSemantics)},
+	tableMethodImpl:             {columnIndex(tableTypeDef), columnCoded(codedMethodDefOrRef), columnCoded(codedMethodDefOrRef)},
+	tableModuleRef:              {columnString},
+	tableTypeSpec:               {columnBlob},
+	tableImplMap:                {columnU16, columnCoded(codedMemberForwarded), columnString, columnIndex(tableModuleRef)},
+	tableFieldRva:               {columnU32, columnIndex(tableField)},
+	tableEncLog:                 {columnU32, columnU32},
+	tableEncMap:                 {columnU32},
+	tableAssembly:               {columnU32, columnU16, columnU16, columnU16, columnU16, columnU32, columnBlob, columnString, columnString},
+	tableAssemblyProcessor:      {columnU32},
+	tableAssemblyOs:             {columnU32, columnU32, columnU32},
+	tableAssemblyRef:            {columnU16, columnU16, columnU16, columnU16, columnU32, columnBlob, columnString, columnString, columnBlob},
+	tableAssemblyRefProcessor:   {columnU32, columnIndex(tableAssemblyRef)},
+	tableAssemblyRefOs:          {columnU32, columnU32, columnU32, columnIndex(tableAssemblyRef)},
+	tableFile:                   {columnU32, columnString, columnBlob},
+	tableExportedType:           {columnU32, columnU32, columnString, columnString, columnCoded(codedImplementation)},
+	tableManifestResource:       {columnU32, columnU32, columnString, columnCoded(codedImplementation)},
+	tableNestedClass:            {columnIndex(tableTypeDef), columnIndex(tableTypeDef)},
+	tableGenericParam:           {columnU16, columnU16, columnCoded(codedTypeOrMethodDef), columnString},
+	tableMethodSpec:             {columnCoded(codedMethodDefOrRef), columnBlob},
+	tableGenericParamConstraint: {columnIndex(tableGenericParam), columnCoded(codedTypeDefOrRef)},
+}
+
+const comImageFlagsIlOnly = 0x1
+
+// signature of the ReadyToRun header, RTR
+const readyToRunSignature = 0x00525452
+
+// metadata of an assembly with the location of its tables and heaps
+type dotNetMetadata struct {
+	rowCounts    [tableCount]uint32
+	tableOffsets [tableCount]int
+	rowSizes     [tableCount]int
+	columnSizes  [tableCount][]int
+	tables       []byte
+	strings      []byte
+	blobs        []byte
+}
+
+// parses the CLR header and the metadata of a .NET assembly, see ECMA-335 II.24 and II.25.3.3
+func getDotNetAssembly(f *pe.File) (*DotNetAssembly, error) {
+	var clrDirectory pe.DataDirectory
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		clrDirectory = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR]
+	case *pe.OptionalHeader64:
+		clrDirectory = oh.DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR]
+	}
+	if clrDirectory.VirtualAddress == 0 {
+		return nil, fmt.Errorf("no CLR header")
+	}
+
+	// CLR header: size, runtime version, metadata directory, flags, entry point and the directories
+	// of resources, strong name signature, code manager table, vtable fixups, export address table
+	// jumps and managed native header
+	clrHeader, err := readPEData(f, clrDirectory.VirtualAddress, 72)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CLR header: %v", err)
+	}
+	metadataRva := binary.LittleEndian.Uint32(clrHeader[8:12])
+	metadataSize := binary.LittleEndian.Uint32(clrHeader[12:16])
+	flags := binary.LittleEndian.Uint32(clrHeader[16:20])
+	readyToRun := false
+	if managedNativeHeaderRva := binary.LittleEndian.Uint32(clrHeader[64:68]); managedNativeHeaderRva != 0 {
+		signature, err := readPEData(f, managedNativeHeaderRva, 4)
+		readyToRun = err == nil && binary.LittleEndian.Uint32(signature) == readyToRunSignature
+	}
+
+	root, err := readPEData(f, metadataRva, metadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .NET metadata: %v", err)
+	}
+	runtimeVersion, metadata, err := parseDotNetMetadata(root)
+	if err != nil {
+		return nil, err
+	}
+
+	assembly := &DotNetAssembly{
+		RuntimeVersion: runtimeVersion,
+		ILOnly:         flags&comImageFlagsIlOnly != 0,
+		ReadyToRun:     readyToRun,
+		References:     []DotNetAssemblyReference{},
+	}
+	if metadata.rowCounts[tableAssembly] > 0 {
+		// major, minor, build and revision number follow the hash algorithm
+		assembly.Version = metadata.readVersion(tableAssembly, 0, 1)
+		assembly.Name = metadata.readString(metadata.readColumn(tableAssembly, 0, 7))
+	}
+	for row := uint32(0); row < metadata.rowCounts[tableAssemblyRef]; row++ {
+		assembly.References = append(assembly.References, DotNetAssemblyReference{
+			Name:    metadata.readString(metadata.readColumn(tableAssemblyRef, row, 6)),
+			Version: metadata.readVersion(tableAssemblyRef, row, 0),
+		})
+	}
+	assembly.TargetFramework = metadata.getTargetFramework()
+	return assembly, nil
+}
+
+// tells the language of a .NET assembly apart by the runtime libraries their compilers reference,
+// returns the language, the source of the evidence and the reason, C# references no runtime library
+// of its own, so it is only chosen by elimination
+func getDotNetLanguage(assembly *DotNetAssembly) (string, string, string) {
+	if !assembly.ILOnly && !assembly.ReadyToRun {
+		// the native code of ReadyToRun images is compiled from IL and not written in C++
+		return "C++/CLI", evidenceStructural, "mixed-mode assembly with native code"
+	}
+	for _, reference := range assembly.References {
+		switch reference.Name {
+		case "FSharp.Core":
+			return "F#", evidenceStructural, "references FSharp.Core"
+		case "Microsoft.VisualBasic", "Microsoft.VisualBasic.Core":
+			return "VB.NET", evidenceStructural, "references " + reference.Name
+		}
+	}
+	return "C#", evidenceElimination, "references neither FSharp.Core nor Microsoft.VisualBasic"
+}
+
+// shortens a target framework to its moniker as used in project files, e.g. .NETCoreApp,Version=v8.0
+// to net8.0, .NETFramework,Version=v4.7.2 to net472 and .NETStandard,Version=v2.0 to netstandard2.0
+func getTargetFrameworkMoniker(targetFramework string) string {
+	identifier, version, found := strings.Cut(targetFramework, ",Version=v")
+	if !found {
+		return targetFramework
+	}
+	switch identifier {
+	case ".NETCoreApp":
+		if strings.HasPrefix(version, "1.") || strings.HasPrefix(version, "2.") || strings.HasPrefix(version, "3.") {
+			return "netcoreapp" + version
+		}
+		return "net" + version
+	case ".NETFramework":
+		return "net" + strings.ReplaceAll(version, ".", "")
+	case ".NETStandard":
+		return "netstandard" + version
+	}
+	return targetFramework
+}
+
+// reads data of a PE file by its relative virtual address
+func readPEData(f *pe.File, rva uint32, size uint32) ([]byte, error) {
+	for _, section := range f.Sections {
+		if rva < section.VirtualAddress || rva-section.VirtualAddress >= max(section.VirtualSize, section.Size) {
+			continue
+		}
+		// the size comes from the file, it is checked before anything gets allocated
+		offset := rva - section.VirtualAddress
+		if offset > section.Size || size > section.Size-offset {
+			return nil, fmt.Errorf("%d bytes at RVA 0x%x exceed section %s", size, rva, section.Name)
+		}
+		data := make([]byte, size)
+		if _, err := section.ReadAt(data, int64(offset)); err != nil {
+			return nil, err
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("RVA 0x%x is not in any section", rva)
+}
+
+// parses the metadata root with its stream headers and the header of the tables stream
+func parseDotNetMetadata(root []byte) (string, *dotNetMetadata, error) {
+	// signature, major and minor version, reserved, length and version string
+	if len(root) < 16 || binary.LittleEndian.Uint32(root[0:4]) != 0x424a5342 {
+		return "", nil, fmt.Errorf("invalid .NET metadata signature")
+	}
+	versionLength := int(binary.LittleEndian.Uint32(root[12:16]))
+	if 16+versionLength+4 > len(root) {
+		return "", nil, fmt.Errorf("invalid .NET metadata version length")
+	}
+	runtimeVersion := string(bytes.TrimRight(root[16:16+versionLength], "\x00"))
+
+	// flags, number of streams and the stream headers: offset, size and 4 byte aligned name
+	offset := 16 + versionLength
+	streamCount := int(binary.LittleEndian.Uint16(root[offset+2:]))
+	offset += 4
+	metadata := &dotNetMetadata{}
+	for i := 0; i < streamCount; i++ {
+		if offset+8 > len(root) {
+			return "", nil, fmt.Errorf("truncated .NET metadata stream headers")
+		}
+		streamOffset := binary.LittleEndian.Uint32(root[offset:])
+		streamSize := binary.LittleEndian.Uint32(root[offset+4:])
+		nameEnd := bytes.IndexByte(root[offset+8:], 0)
+		if nameEnd < 0 {
+			return "", nil, fmt.Errorf("truncated .NET metadata stream headers")
+		}
+		name := string(root[offset+8 : offset+8+nameEnd])
+		offset += 8 + (nameEnd+4)&^3
+		if uint64(streamOffset)+uint64(streamSize) > uint64(len(root)) {
+			return "", nil, fmt.Errorf("invalid .NET metadata stream %s", name)
+		}
+		stream := root[streamOffset : streamOffset+streamSize]
+		switch name {
+		case "#~", "#-":
+			metadata.tables = stream
+		case "#Strings":
+			metadata.strings = stream
+		case "#Blob":
+			metadata.blobs = stream
+		}
+	}
+	if metadata.tables == nil {
+		return "", nil, fmt.Errorf("no .NET metadata tables")
+	}
+	if err := metadata.parseTablesHeader(); err != nil {
+		return "", nil, err
+	}
+	return runtimeVersion, metadata, nil
+}
+
+// reads the row counts of the tables stream and calculates where each table starts
+func (m *dotNetMetadata) parseTablesHeader() error {
+	// reserved, major and minor version, heap sizes, reserved, valid and sorted table bit masks
+	if len(m.tables) < 24 {
+		return fmt.Errorf("truncated .NET metadata tables")
+	}
+	heapSizes := m.tables[6]
+	valid := binary.LittleEndian.Uint64(m.tables[8:16])
+	offset := 24
+	for table := 0; table < 64; table++ {
+		if valid&(1<<table) == 0 {
+			continue
+		}
+		if table >= tableCount {
+			return fmt.Errorf("unsupported .NET metadata table 0x%x", table)
+		}
+		if offset+4 > len(m.tables) {
+			return fmt.Errorf("truncated .NET metadata tables")
+		}
+		m.rowCounts[table] = binary.LittleEndian.Uint32(m.tables[offset:])
+		offset += 4
+	}
+	if heapSizes&0x40 != 0 {
+		// extra data of uncompressed metadata
+		offset += 4
+	}
+
+	heapIndexSize := func(bit byte) int {
+		if heapSizes&bit != 0 {
+			return 4
+		}
+		return 2
+	}
+	for table := 0; table < tableCount; table++ {
+		for _, column := range metadataTableColumns[table] {
+			size := column.size
+			switch {
+			case column.heap == 's':
+				size = heapIndexSize(0x01)
+			case column.heap == 'g':
+				size = heapIndexSize(0x02)
+			case column.heap == 'b':
+				size = heapIndexSize(0x04)
+			case column.table >= 0:
+				size = 2
+				if m.rowCounts[column.table] > 0xffff {
+					size = 4
+				}
+			case column.coded != nil:
+				// the rows of all referenced tables must fit next to the tag bits
+				tagBits := bits.Len(uint(len(column.coded) - 1))
+				size = 2
+				for _, codedTable := range column.coded {
+					if codedTable >= 0 && m.rowCounts[codedTable] >= 1<<(16-tagBits) {
+						size = 4
+					}
+				}
+			}
+			m.columnSizes[table] = append(m.columnSizes[table], size)
+			m.rowSizes[table] += size
+		}
+		m.tableOffsets[table] = offset
+		offset += m.rowSizes[table] * int(m.rowCounts[table])
+	}
+	if offset > len(m.tables) {
+		return fmt.Errorf("truncated .NET metadata tables")
+	}
+	return nil
+}
+
+// reads a column of a table row, row numbers start at 0 unlike metadata tokens
+func (m *dotNetMetadata) readColumn(table int, row uint32, column int) uint32 {
+	offset := m.tableOffsets[table] + int(row)*m.rowSizes[table]
+	for i := 0; i < column; i++ {
+		offset += m.columnSizes[table][i]
+	}
+	if m.columnSizes[table][column] == 4 {
+		return binary.LittleEndian.Uint32(m.tables[offset:])
+	}
+	return uint32(binary.LittleEndian.Uint16(m.tables[offset:]))
+}
+
+// reads four 16 bit version columns starting at a column, e.g. 8.0.0.0
+func (m *dotNetMetadata) readVersion(table int, row uint32, column int) string {
+	return fmt.Sprintf("%d.%d.%d.%d", m.readColumn(table, row, column), m.readColumn(table, row, column+1),
+		m.readColumn(table, row, column+2), m.readColumn(table, row, column+3))
+}
+
+func (m *dotNetMetadata) readString(index uint32) string {
+	if int(index) >= len(m.strings) {
+		return ""
+	}
+	end := bytes.IndexByte(m.strings[index:], 0)
+	if end < 0 {
+		return ""
+	}
+	return string(m.strings[index : int(index)+end])
+}
+
+// reads a blob with its compressed length prefix, see ECMA-335 II.23.2
+func (m *dotNetMetadata) readBlob(index uint32) []byte {
+	if int(index) >= len(m.blobs) {
+		return nil
+	}
+	data := m.blobs[index:]
+	var length, prefix int
+	switch {
+	case data[0]&0x80 == 0:
+		length, prefix = int(data[0]), 1
+	case data[0]&0xc0 == 0x80 && len(data) >= 2:
+		length, prefix = int(data[0]&0x3f)<<8|int(data[1]), 2
+	case data[0]&0xe0 == 0xc0 && len(data) >= 4:
+		length, prefix = int(data[0]&0x1f)<<24|int(data[1])<<16|int(data[2])<<8|int(data[3]), 4
+	default:
+		return nil
+	}
+	if prefix+length > len(data) {
+		return nil
+	}
+	return data[prefix : prefix+length]
+}
+
+// returns the framework of the TargetFrameworkAttribute of the assembly, e.g. .NETCoreApp,Version=v8.0
+func (m *dotNetMetadata) getTargetFramework() string {
+	for row := uint32(0); row < m.rowCounts[tableCustomAttribute]; row++ {
+		// attributes of the assembly
+		if parent, _ := decodeCodedIndex(codedHasCustomAttribute, m.readColumn(tableCustomAttribute, row, 0)); parent != tableAssembly {
+			continue
+		}
+		// constructor of an attribute type defined in another assembly
+		constructor, memberRef := decodeCodedIndex(codedCustomAttributeType, m.readColumn(tableCustomAttribute, row, 1))
+		if constructor != tableMemberRef || memberRef == 0 || memberRef > m.rowCounts[tableMemberRef] {
+			continue
+		}
+		class, typeRef := decodeCodedIndex(codedMemberRefParent, m.readColumn(tableMemberRef, memberRef-1, 0))
+		if class != tableTypeRef || typeRef == 0 || typeRef > m.rowCounts[tableTypeRef] {
+			continue
+		}
+		if m.readString(m.readColumn(tableTypeRef, typeRef-1, 1)) != "TargetFrameworkAttribute" {
+			continue
+		}
+
+		// prolog 0x0001 followed by the framework name as serialized string
+		value := m.readBlob(m.readColumn(tableCustomAttribute, row, 2))
+		if len(value) < 3 || value[0] != 1 || value[1] != 0 || int(value[2])+3 > len(value) || value[2]&0x80 != 0 {
+			continue
+		}
+		return string(value[3 : 3+int(value[2])])
+	}
+	return ""
+}
+
+// ReadyToRun images compiled for other operating systems than Windows have their machine XOR'ed with
+// an OS specific value, which debug/pe rejects, see ReadyToRunOverview.md of the .NET runtime
+var readyToRunMachineOverrides = []uint16{
+	0x4644, // Apple
+	0xadc4, // FreeBSD
+	0x7b79, // Linux
+	0x1993, // NetBSD
+	0x1992, // SunOS
+}
+
+// reader of a PE file that replaces the machine of its file header
+type peMachineReaderAt struct {
+	io.ReaderAt
+	machineOffset int64
+	machine       [2]byte
+}
+
+func (r peMachineReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.ReaderAt.ReadAt(p, off)
+	for i := range r.machine {
+		if position := r.machineOffset + int64(i) - off; position >= 0 && position < int64(n) {
+			p[position] = r.machine[i]
+		}
+	}
+	return n, err
+}
+
+// opens a PE file, including ReadyToRun assemblies compiled for Linux or macOS
+func newPEFile(r io.ReaderAt) (*pe.File, error) {
+	f, err := pe.NewFile(r)
+	if err == nil {
+		return f, nil
+	}
+
+	// the PE header follows the DOS header at the offset at 0x3c
+	var header [4]byte
+	if _, readErr := r.ReadAt(header[:], 0x3c); readErr != nil {
+		return nil, err
+	}
+	machineOffset := int64(binary.LittleEndian.Uint32(header[:])) + 4
+	if _, readErr := r.ReadAt(header[:2], machineOffset); readErr != nil {
+		return nil, err
+	}
+	machine := binary.LittleEndian.Uint16(header[:2])
+	for _, override := range readyToRunMachineOverrides {
+		switch machine ^ override {
+		case pe.IMAGE_FILE_MACHINE_AMD64, pe.IMAGE_FILE_MACHINE_I386, pe.IMAGE_FILE_MACHINE_ARM64, pe.IMAGE_FILE_MACHINE_ARMNT:
+			reader := peMachineReaderAt{ReaderAt: r, machineOffset: machineOffset}
+			binary.LittleEndian.PutUint16(reader.machine[:], machine^override)
+			return pe.NewFile(reader)
+		}
+	}
+	return nil, err
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"debug/pe"
+	"encoding/binary"
+	"runtime"
+	"testing"
+)
+
+// reads the metadata root of an assembly like getDotNetAssembly
+func readTestMetadataRoot(t *testing.T, path string) []byte {
+	f, err := pe.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	clrDirectory := f.OptionalHeader.(*pe.OptionalHeader32).DataDirectory[pe.IMAGE_DIRECTORY_ENTRY_COM_DESCRIPTOR]
+	clrHeader, err := readPEData(f, clrDirectory.VirtualAddress, 72)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := readPEData(f, binary.LittleEndian.Uint32(clrHeader[8:12]), binary.LittleEndian.Uint32(clrHeader[12:16]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// returns the offset of the first stream header with a name in a metadata root
+func findTestStreamHeader(t *testing.T, root []byte, name string) int {
+	index := bytes.Index(root, append([]byte(name), 0))
+	if index < 8 {
+		t.Fatalf("no stream %s", name)
+	}
+	return index - 8
+}
+
+func TestReadPEData(t *testing.T) {
+	f, err := pe.Open("testdata/language-corpus/hello-csharp.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	section := f.Sections[0]
+
+	tests := []struct {
+		name    string
+		rva     uint32
+		size    uint32
+		wantErr bool
+	}{
+		{"whole section", section.VirtualAddress, section.Size, false},
+		{"end of the section", section.VirtualAddress + section.Size - 4, 4, false},
+		{"beyond the end of the section", section.VirtualAddress + section.Size - 4, 5, true},
+		// sizes of crafted files are rejected before they get allocated
+		{"size of 4GB", section.VirtualAddress, 0xffffffff, true},
+		{"size that overflows the RVA", section.VirtualAddress + 16, 0xfffffff8, true},
+		{"no section", 0xfffffff0, 4, true},
+	}
+	var before, after runtime.MemStats
+	for _, test := range tests {
+		runtime.ReadMemStats(&before)
+		data, err := readPEData(f, test.rva, test.size)
+		runtime.ReadMemStats(&after)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+		} else if err == nil && len(data) != int(test.size) {
+			t.Errorf("%s: got %d bytes", test.name, len(data))
+		} else if allocated := after.TotalAlloc - before.TotalAlloc; err != nil && allocated > 1<<20 {
+			t.Errorf("%s: allocated %d bytes", test.name, allocated)
+		}
+	}
+}
+
+func TestParseDotNetMetadata(t *testing.T) {
+	root := readTestMetadataRoot(t, "testdata/language-corpus/hello-csharp.dll")
+	versionLength := int(binary.LittleEndian.Uint32(root[12:16]))
+	tablesHeader := findTestStreamHeader(t, root, "#~")
+	tablesOffset := int(binary.LittleEndian.Uint32(root[tablesHeader:]))
+
+	// returns a copy of the root changed by a function
+	mutate := func(change func(root []byte)) []byte {
+		changed := bytes.Clone(root)
+		change(changed)
+		return changed
+	}
+	tests := []struct {
+		name    string
+		root    []byte
+		wantErr bool
+	}{
+		{"valid", root, false},
+		{"empty", nil, true},
+		{"truncated header", root[:12], true},
+		{"wrong signature", mutate(func(r []byte) { r[0] = 'X' }), true},
+		{"version longer than the root", mutate(func(r []byte) { binary.LittleEndian.PutUint32(r[12:], 0xffffffff) }), true},
+		{"truncated version", root[:16+versionLength], true},
+		{"too many streams", mutate(func(r []byte) { binary.LittleEndian.PutUint16(r[16+versionLength+2:], 0xffff) }), true},
+		{"truncated stream headers", root[:tablesHeader+10], true},
+		{"stream beyond the root", mutate(func(r []byte) { binary.LittleEndian.PutUint32(r[tablesHeader:], uint32(len(r))) }), true},
+		{"stream size beyond the root", mutate(func(r []byte) { binary.LittleEndian.PutUint32(r[tablesHeader+4:], 0xffffffff) }), true},
+		{"no tables stream", mutate(func(r []byte) { r[tablesHeader+9] = 'X' }), true},
+		{"unsupported table", mutate(func(r []byte) { r[tablesOffset+15] |= 0x80 }), true},
+		{"more rows than the tables stream", mutate(func(r []byte) { binary.LittleEndian.PutUint32(r[tablesOffset+24:], 0xffffff) }), true},
+		{"truncated tables", mutate(func(r []byte) { binary.LittleEndian.PutUint32(r[tablesHeader+4:], 20) }), true},
+	}
+	for _, test := range tests {
+		runtimeVersion, metadata, err := parseDotNetMetadata(test.root)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && (runtimeVersion != "v4.0.30319" || metadata.rowCounts[tableAssemblyRef] == 0) {
+			t.Errorf("%s: got runtime version %q and %d assembly references", test.name, runtimeVersion, metadata.rowCounts[tableAssemblyRef])
+		}
+	}
+
+	// no truncation or corrupted byte may panic, the metadata is read from untrusted files
+	for length := range root {
+		if _, metadata, err := parseDotNetMetadata(root[:length]); err == nil {
+			metadata.getTargetFramework()
+		}
+	}
+	for offset := range root {
+		if _, metadata, err := parseDotNetMetadata(mutate(func(r []byte) { r[offset] ^= 0xff })); err == nil {
+			metadata.getTargetFramework()
+		}
+	}
+}
+
+func TestGetTargetFramework(t *testing.T) {
+	root := readTestMetadataRoot(t, "testdata/language-corpus/hello-csharp.dll")
+
+	// changes a column of all rows of a table
+	setColumn := func(m *dotNetMetadata, table int, column int, value func(row uint32) uint32) {
+		for row := uint32(0); row < m.rowCounts[table]; row++ {
+			offset := m.tableOffsets[table] + int(row)*m.rowSizes[table]
+			for i := 0; i < column; i++ {
+				offset += m.columnSizes[table][i]
+			}
+			if m.columnSizes[table][column] == 4 {
+				binary.LittleEndian.PutUint32(m.tables[offset:], value(row))
+			} else {
+				binary.LittleEndian.PutUint16(m.tables[offset:], uint16(value(row)))
+			}
+		}
+	}
+	tests := []struct {
+		name   string
+		change func(m *dotNetMetadata)
+		want   string
+	}{
+		{"valid", func(m *dotNetMetadata) {}, ".NETCoreApp,Version=v8.0"},
+		{"parent tag of no table", func(m *dotNetMetadata) {
+			setColumn(m, tableCustomAttribute, 0, func(row uint32) uint32 { return 31 })
+		}, ""},
+		{"constructor tag of no table", func(m *dotNetMetadata) {
+			setColumn(m, tableCustomAttribute, 1, func(row uint32) uint32 { return 1<<3 | 7 })
+		}, ""},
+		{"constructor beyond the member references", func(m *dotNetMetadata) {
+			setColumn(m, tableCustomAttribute, 1, func(row uint32) uint32 { return (m.rowCounts[tableMemberRef]+1)<<3 | 3 })
+		}, ""},
+		{"class tag of no table", func(m *dotNetMetadata) {
+			setColumn(m, tableMemberRef, 0, func(row uint32) uint32 { return 1<<3 | 7 })
+		}, ""},
+		{"class beyond the type references", func(m *dotNetMetadata) {
+			setColumn(m, tableMemberRef, 0, func(row uint32) uint32 { return (m.rowCounts[tableTypeRef]+1)<<3 | 1 })
+		}, ""},
+		{"value beyond the blobs", func(m *dotNetMetadata) {
+			setColumn(m, tableCustomAttribute, 2, func(row uint32) uint32 { return uint32(len(m.blobs)) })
+		}, ""},
+		{"no blobs", func(m *dotNetMetadata) { m.blobs = nil }, ""},
+		{"truncated blobs", func(m *dotNetMetadata) { m.blobs = m.blobs[:len(m.blobs)/2] }, ""},
+		{"no strings", func(m *dotNetMetadata) { m.strings = nil }, ""},
+	}
+	for _, test := range tests {
+		_, metadata, err := parseDotNetMetadata(bytes.Clone(root))
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.change(metadata)
+		if got := metadata.getTargetFramework(); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestGetDotNetLanguage(t *testing.T) {
+	tests := []struct {
+		name         string
+		assembly     DotNetAssembly
+		wantLanguage string
+		wantSource   string
+	}{
+		{"C#", DotNetAssembly{ILOnly: true, References: []DotNetAssemblyReference{{Name: "System.Runtime"}}}, "C#", evidenceElimination},
+		{"F#", DotNetAssembly{ILOnly: true, References: []DotNetAssemblyReference{{Name: "FSharp.Core"}}}, "F#", evidenceStructural},
+		{"VB.NET", DotNetAssembly{ILOnly: true, References: []DotNetAssemblyReference{{Name: "Microsoft.VisualBasic.Core"}}}, "VB.NET", evidenceStructural},
+		{"C++/CLI", DotNetAssembly{}, "C++/CLI", evidenceStructural},
+		{"ReadyToRun", DotNetAssembly{ReadyToRun: true}, "C#", evidenceElimination},
+	}
+	for _, test := range tests {
+		language, source, _ := getDotNetLanguage(&test.assembly)
+		if language != test.wantLanguage || source != test.wantSource {
+			t.Errorf("%s: got %s by %s, want %s by %s", test.name, language, source, test.wantLanguage, test.wantSource)
+		}
+	}
+	if evidenceWeights[evidenceElimination] >= evidenceWeights[evidenceStructural]/2 {
+		t.Errorf("C# by elimination weighs %v, structural evidence %v", evidenceWeights[evidenceElimination], evidenceWeights[evidenceStructural])
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+)
+
+var (
+	gDyldSharedCacheTextSizes map[string]int64
+)
+
+// returns the locations of the dyld shared cache of the running system, the cache moved into the
+// cryptex of the OS volume as of macOS Ventura (13)
+func getDyldSharedCachePaths() []string {
+	var architectures []string
+	switch runtime.GOARCH {
+	case "arm64":
+		architectures = []string{"arm64e", "arm64"}
+	case "amd64":
+		architectures = []string{"x86_64h", "x86_64"}
+	}
+	var paths []string
+	for _, directory := range []string{
+		"/System/Volumes/Preboot/Cryptexes/OS/System/Library/dyld/",
+		"/System/Library/dyld/",
+	} {
+		for _, architecture := range architectures {
+			paths = append(paths, directory+"dyld_shared_cache_"+architecture)
+		}
+	}
+	return paths
+}
+
+// returns the __TEXT segment size of a library that only exists in the dyld shared cache, as of
+// macOS Big Sur (11) core system libraries are no longer placed in /usr/lib/ or /System/Library/
+func getDyldSharedCacheTextSize(libraryPath string) (int64, error) {
+	if gDyldSharedCacheTextSizes == nil {
+		// initialize static cache instance
+		gDyldSharedCacheTextSizes = map[string]int64{}
+		for _, cachePath := range getDyldSharedCachePaths() {
+			file, err := os.Open(cachePath)
+			if err != nil {
+				continue
+			}
+			textSizes, err := parseDyldSharedCacheTextSizes(file)
+			file.Close()
+			if err != nil {
+				return 0, fmt.Errorf("failed to read dyld shared cache %s, error: %v", cachePath, err)
+			}
+			gDyldSharedCacheTextSizes = textSizes
+			break
+		}
+	}
+
+	size, ok := gDyldSharedCacheTextSizes[libraryPath]
+	if !ok {
+		return 0, fmt.Errorf("library %s not found on disk or in the dyld shared cache", libraryPath)
+	}
+	return size, nil
+}
+
+// parses the image text infos of a dyld shared cache (see dyld_cache_format.h of Apple's dyld), the
+// same information that `dyld_info -segments` prints for cached libraries
+func parseDyldSharedCacheTextSizes(reader io.ReaderAt) (map[string]int64, error) {
+	header := make([]byte, 0x1c8)
+	n, err := reader.ReadAt(header, 0)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	header = header[:n]
+	if !bytes.HasPrefix(header, []byte("dyld_v1")) || len(header) < 0x98 {
+		return nil, fmt.Errorf("invalid dyld shared cache header")
+	}
+
+	// the header grew over time, fields only exist if they are placed before the mappings
+	byteOrder := binary.LittleEndian
+	mappingOffset := byteOrder.Uint32(header[0x10:])
+	if mappingOffset < 0x98 {
+		return nil, fmt.Errorf("dyld shared cache has no image text infos")
+	}
+	imagesTextOffset := byteOrder.Uint64(header[0x88:])
+	imagesTextCount := byteOrder.Uint64(header[0x90:])
+
+	readPath := func(offset uint32) (string, error) {
+		buffer := make([]byte, 1024)
+		n, err := reader.ReadAt(buffer, int64(offset))
+		if err != nil && err != io.EOF {
+			return "", err
+		}
+		end := bytes.IndexByte(buffer[:n], 0)
+		if end < 0 {
+			return "", fmt.Errorf("invalid path at offset %d", offset)
+		}
+		return string(buffer[:end]), nil
+	}
+
+	// dyld_cache_image_text_info: uuid[16], loadAddress, textSegmentSize, pathOffset
+	textSizes := map[string]int64{}
+	textSizesByAddress := map[uint64]int64{}
+	entry := make([]byte, 32)
+	for i := uint64(0); i < imagesTextCount; i++ {
+		if _, err := reader.ReadAt(entry, int64(imagesTextOffset+i*32)); err != nil {
+			return nil, err
+		}
+		loadAddress := byteOrder.Uint64(entry[16:])
+		textSegmentSize := int64(byteOrder.Uint32(entry[24:]))
+		path, err := readPath(byteOrder.Uint32(entry[28:]))
+		if err != nil {
+			return nil, err
+		}
+		textSizes[path] = textSegmentSize
+		textSizesByAddress[loadAddress] = textSegmentSize
+	}
+
+	// dyld_cache_image_info: address, modTime, inode, pathFileOffset, pad
+	// the image infos also list aliases like /usr/lib/libc++.dylib for /usr/lib/libc++.1.dylib
+	var imagesOffset, imagesCount uint32
+	if mappingOffset >= 0x1c8 && len(header) >= 0x1c8 {
+		imagesOffset = byteOrder.Uint32(header[0x1c0:])
+		imagesCount = byteOrder.Uint32(header[0x1c4:])
+	} else {
+		imagesOffset = byteOrder.Uint32(header[0x18:])
+		imagesCount = byteOrder.Uint32(header[0x1c:])
+	}
+	for i := uint32(0); i < imagesCount; i++ {
+		if _, err := reader.ReadAt(entry, int64(imagesOffset)+int64(i)*32); err != nil {
+			return nil, err
+		}
+		textSegmentSize, ok := textSizesByAddress[byteOrder.Uint64(entry[0:])]
+		if !ok {
+			continue
+		}
+		path, err := readPath(byteOrder.Uint32(entry[24:]))
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := textSizes[path]; !ok {
+			textSizes[path] = textSegmentSize
+		}
+	}
+
+	return textSizes, nil
+}
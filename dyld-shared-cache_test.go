@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// builds a dyld shared cache with image text infos and image infos but no mappings or images, the
+// header layout is the one of macOS 11 and later or the one of older releases without the fields
+// starting at 0x98, see dyld_cache_format.h of Apple's dyld
+func buildDyldSharedCache(newHeader bool) []byte {
+	type image struct {
+		path        string
+		loadAddress uint64
+		textSize    uint32
+	}
+	images := []image{
+		{"/usr/lib/libSystem.B.dylib", 0x7ff800001000, 0x4000},
+		{"/usr/lib/libc++.1.dylib", 0x7ff800005000, 0x8000},
+	}
+	// aliases are only listed in the image infos
+	aliases := []image{{"/usr/lib/libc++.dylib", 0x7ff800005000, 0}}
+
+	headerSize := 0x98
+	if newHeader {
+		headerSize = 0x1c8
+	}
+	textInfosOffset := headerSize
+	imageInfosOffset := textInfosOffset + len(images)*32
+	pathsOffset := imageInfosOffset + (len(images)+len(aliases))*32
+
+	data := make([]byte, pathsOffset)
+	copy(data, "dyld_v1  x86_64h")
+	byteOrder := binary.LittleEndian
+	byteOrder.PutUint32(data[0x10:], uint32(headerSize))
+	byteOrder.PutUint64(data[0x88:], uint64(textInfosOffset))
+	byteOrder.PutUint64(data[0x90:], uint64(len(images)))
+	if newHeader {
+		byteOrder.PutUint32(data[0x1c0:], uint32(imageInfosOffset))
+		byteOrder.PutUint32(data[0x1c4:], uint32(len(images)+len(aliases)))
+	} else {
+		byteOrder.PutUint32(data[0x18:], uint32(imageInfosOffset))
+		byteOrder.PutUint32(data[0x1c:], uint32(len(images)+len(aliases)))
+	}
+	for i, image := range append(images, aliases...) {
+		pathOffset := len(data)
+		data = append(data, image.path...)
+		data = append(data, 0)
+		if i < len(images) {
+			textInfo := data[textInfosOffset+i*32:]
+			byteOrder.PutUint64(textInfo[16:], image.loadAddress)
+			byteOrder.PutUint32(textInfo[24:], image.textSize)
+			byteOrder.PutUint32(textInfo[28:], uint32(pathOffset))
+		}
+		imageInfo := data[imageInfosOffset+i*32:]
+		byteOrder.PutUint64(imageInfo[0:], image.loadAddress)
+		byteOrder.PutUint32(imageInfo[24:], uint32(pathOffset))
+	}
+	return data
+}
+
+func TestParseDyldSharedCacheTextSizes(t *testing.T) {
+	expected := map[string]int64{
+		"/usr/lib/libSystem.B.dylib": 0x4000,
+		"/usr/lib/libc++.1.dylib":    0x8000,
+		"/usr/lib/libc++.dylib":      0x8000,
+	}
+	for _, newHeader := range []bool{true, false} {
+		textSizes, err := parseDyldSharedCacheTextSizes(bytes.NewReader(buildDyldSharedCache(newHeader)))
+		if err != nil {
+			t.Errorf("new header %t: %v", newHeader, err)
+			continue
+		}
+		if !reflect.DeepEqual(textSizes, expected) {
+			t.Errorf("new header %t: got %v, expected %v", newHeader, textSizes, expected)
+		}
+	}
+}
+
+func TestParseDyldSharedCacheTextSizesInvalid(t *testing.T) {
+	cache := buildDyldSharedCache(true)
+	truncated := cache[:0x1c8+16]
+	noTextInfos := append([]byte{}, cache...)
+	binary.LittleEndian.PutUint32(noTextInfos[0x10:], 0x70)
+	for name, data := range map[string][]byte{
+		"empty":         nil,
+		"no magic":      make([]byte, 0x1c8),
+		"truncated":     truncated,
+		"no text infos": noTextInfos,
+	} {
+		if _, err := parseDyldSharedCacheTextSizes(bytes.NewReader(data)); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
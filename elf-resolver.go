@@ -0,0 +1,453 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	gLdSoCaches map[FileSystem]map[string][]string
+)
+
+// LibraryDependency describes a single library requested by an object and where it was resolved to
+type LibraryDependency struct {
+	Name       string // library name as requested, e.g. libc.so.6
+	Path       string // resolved path, empty if the library could not be found
+	NeededBy   string // path of the object that requested the library
+	ResolvedBy string // search step that found the library, e.g. DT_RUNPATH or ld.so.cache
+}
+
+// LibraryDependencyGraph holds the transitive library dependencies of an executable
+type LibraryDependencyGraph struct {
+	Executable   string
+	Libraries    []string                       // unique resolved library paths in load order
+	Dependencies map[string][]LibraryDependency // direct dependencies keyed by the path of the requesting object
+	Unresolved   []LibraryDependency            // libraries that could not be found
+}
+
+// elfObject holds the dynamic section information of an ELF file needed to resolve its dependencies
+type elfObject struct {
+	path        string
+	class       elf.Class
+	machine     elf.Machine
+	soname      string
+	interpreter string
+	needed      []string
+	rpath       []string
+	runpath     []string
+	hasRunpath  bool
+}
+
+type queuedElfObject struct {
+	object     *elfObject
+	rpathChain [][]string // DT_RPATH lists of the object and its loaders, see ld.so(8)
+}
+
+// resolves the transitive DT_NEEDED dependencies of an ELF executable the same way ld.so(8) does,
+// without executing the binary or its loader (unlike ldd)
+func resolveElfDependencies(fsys FileSystem, exePath string, ldLibraryPath []string) (LibraryDependencyGraph, error) {
+	graph := LibraryDependencyGraph{
+		Executable:   exePath,
+		Dependencies: map[string][]LibraryDependency{},
+	}
+
+	executable, err := openElfObject(fsys, exePath)
+	if err != nil {
+		return graph, err
+	}
+	graph.Executable = executable.path
+
+	// $LIB is the directory ld.so was installed to, e.g. lib64 or lib/x86_64-linux-gnu
+	lib := getElfLibDirectory(fsys, executable)
+
+	// libraries that are already loaded, by name and by path
+	loadedByName := map[string]string{}
+	loadedByPath := map[string]bool{executable.path: true}
+	candidates := map[string]*elfObject{}
+
+	// the dynamic loader itself is mapped into every dynamically linked process
+	if executable.interpreter != "" {
+		dependency := LibraryDependency{
+			Name:       filepath.Base(executable.interpreter),
+			NeededBy:   executable.path,
+			ResolvedBy: "PT_INTERP",
+		}
+		if interpreter := loadElfCandidate(fsys, executable.interpreter, executable, candidates); interpreter != nil {
+			dependency.Path = interpreter.path
+			graph.Libraries = append(graph.Libraries, interpreter.path)
+			loadedByPath[interpreter.path] = true
+			loadedByName[dependency.Name] = interpreter.path
+			if interpreter.soname != "" {
+				loadedByName[interpreter.soname] = interpreter.path
+			}
+		} else {
+			graph.Unresolved = append(graph.Unresolved, dependency)
+		}
+		graph.Dependencies[executable.path] = append(graph.Dependencies[executable.path], dependency)
+	}
+
+	var executableRpath [][]string
+	if !executable.hasRunpath {
+		executableRpath = [][]string{executable.rpath}
+	}
+	// ld.so loads dependencies in breadth-first order
+	queue := []queuedElfObject{{object: executable, rpathChain: executableRpath}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, name := range current.object.needed {
+			dependency := LibraryDependency{
+				Name:     name,
+				NeededBy: current.object.path,
+			}
+			if path, ok := loadedByName[name]; ok {
+				dependency.Path = path
+				dependency.ResolvedBy = "already loaded"
+				graph.Dependencies[current.object.path] = append(graph.Dependencies[current.object.path], dependency)
+				continue
+			}
+
+			library, resolvedBy := findElfLibrary(fsys, name, current, executable, lib, ldLibraryPath, candidates)
+			if library == nil {
+				graph.Unresolved = append(graph.Unresolved, dependency)
+				graph.Dependencies[current.object.path] = append(graph.Dependencies[current.object.path], dependency)
+				continue
+			}
+			dependency.Path = library.path
+			dependency.ResolvedBy = resolvedBy
+			graph.Dependencies[current.object.path] = append(graph.Dependencies[current.object.path], dependency)
+
+			loadedByName[name] = library.path
+			if library.soname != "" {
+				loadedByName[library.soname] = library.path
+			}
+			if loadedByPath[library.path] {
+				continue
+			}
+			loadedByPath[library.path] = true
+			graph.Libraries = append(graph.Libraries, library.path)
+
+			// a DT_RUNPATH only disables the DT_RPATH of the object itself, the DT_RPATH of its loaders
+			// still applies to the libraries it loads
+			rpathChain := current.rpathChain
+			if !library.hasRunpath {
+				rpathChain = append([][]string{library.rpath}, current.rpathChain...)
+			}
+			queue = append(queue, queuedElfObject{object: library, rpathChain: rpathChain})
+		}
+	}
+
+	return graph, nil
+}
+
+// searches a library in the order documented in ld.so(8)
+func findElfLibrary(fsys FileSystem, name string, loader queuedElfObject, executable *elfObject, lib string, ldLibraryPath []string, candidates map[string]*elfObject) (*elfObject, string) {
+	// names containing a slash are used as-is
+	if strings.Contains(name, "/") {
+		path := expandElfDynamicString(name, loader.object, lib)
+		if library := loadElfCandidate(fsys, path, executable, candidates); library != nil {
+			return library, "DT_NEEDED path"
+		}
+		return nil, ""
+	}
+
+	type searchStep struct {
+		name        string
+		directories []string
+	}
+	var steps []searchStep
+	// DT_RPATH is ignored if the requesting object has a DT_RUNPATH
+	if !loader.object.hasRunpath {
+		for _, rpath := range loader.rpathChain {
+			steps = append(steps, searchStep{"DT_RPATH", rpath})
+		}
+	}
+	steps = append(steps, searchStep{"LD_LIBRARY_PATH", ldLibraryPath})
+	steps = append(steps, searchStep{"DT_RUNPATH", loader.object.runpath})
+
+	for _, step := range steps {
+		for _, directory := range step.directories {
+			if directory == "" {
+				continue
+			}
+			directory = expandElfDynamicString(directory, loader.object, lib)
+			path := filepath.Join(directory, name)
+			if library := loadElfCandidate(fsys, path, executable, candidates); library != nil {
+				return library, step.name
+			}
+		}
+	}
+
+	for _, path := range lookupLdSoCache(fsys, name) {
+		if library := loadElfCandidate(fsys, path, executable, candidates); library != nil {
+			return library, "ld.so.cache"
+		}
+	}
+
+	for _, directory := range defaultElfLibraryDirectories(executable) {
+		path := filepath.Join(directory, name)
+		if library := loadElfCandidate(fsys, path, executable, candidates); library != nil {
+			return library, "default path"
+		}
+	}
+
+	return nil, ""
+}
+
+// opens a library candidate and checks it can be loaded into the executable
+func loadElfCandidate(fsys FileSystem, path string, executable *elfObject, candidates map[string]*elfObject) *elfObject {
+	if candidate, ok := candidates[path]; ok {
+		return candidate
+	}
+	candidate, err := openElfObject(fsys, path)
+	if err != nil ||
+		candidate.class != executable.class ||
+		candidate.machine != executable.machine {
+		// ld.so silently skips libraries of a different ELF class or architecture
+		candidate = nil
+	}
+	candidates[path] = candidate
+	return candidate
+}
+
+func openElfObject(fsys FileSystem, path string) (*elfObject, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ELF file %s: %v", path, err)
+	}
+	defer file.Close()
+	elfFile, err := elf.NewFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ELF file %s: %v", path, err)
+	}
+	defer elfFile.Close()
+
+	object := &elfObject{
+		path:    path,
+		class:   elfFile.Class,
+		machine: elfFile.Machine,
+	}
+	// $ORIGIN refers to the directory of the object with all symlinks resolved
+	if realPath, err := fsys.EvalSymlinks(path); err == nil {
+		object.path = realPath
+	}
+
+	for _, prog := range elfFile.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+		data := make([]byte, prog.Filesz)
+		if _, err := prog.ReadAt(data, 0); err == nil {
+			object.interpreter = string(bytes.TrimRight(data, "\x00"))
+		}
+	}
+
+	// statically linked executables have no dynamic section
+	if elfFile.Section(".dynamic") == nil {
+		return object, nil
+	}
+	object.needed, _ = elfFile.DynString(elf.DT_NEEDED)
+	if sonames, _ := elfFile.DynString(elf.DT_SONAME); len(sonames) > 0 {
+		object.soname = sonames[0]
+	}
+	rpaths, _ := elfFile.DynString(elf.DT_RPATH)
+	for _, rpath := range rpaths {
+		object.rpath = append(object.rpath, strings.Split(rpath, ":")...)
+	}
+	runpaths, _ := elfFile.DynString(elf.DT_RUNPATH)
+	for _, runpath := range runpaths {
+		object.runpath = append(object.runpath, strings.Split(runpath, ":")...)
+	}
+	object.hasRunpath = len(runpaths) > 0
+
+	return object, nil
+}
+
+// expands the dynamic string tokens $ORIGIN, $LIB and $PLATFORM, see ld.so(8)
+func expandElfDynamicString(value string, object *elfObject, lib string) string {
+	if !strings.Contains(value, "$") {
+		return value
+	}
+	replacer := strings.NewReplacer(
+		"${ORIGIN}", filepath.Dir(object.path),
+		"$ORIGIN", filepath.Dir(object.path),
+		"${LIB}", lib,
+		"$LIB", lib,
+		"${PLATFORM}", elfPlatform(object.machine),
+		"$PLATFORM", elfPlatform(object.machine),
+	)
+	return replacer.Replace(value)
+}
+
+// returns the value of $LIB, which glibc sets at build time to the directory it installs ld.so to, so
+// it is derived from the real path of the dynamic loader of the executable, e.g. lib64 on Fedora and
+// lib/x86_64-linux-gnu on Debian, whose /lib64/ld-linux-x86-64.so.2 is a symlink into the latter
+func getElfLibDirectory(fsys FileSystem, executable *elfObject) string {
+	interpreter := executable.interpreter
+	if realPath, err := fsys.EvalSymlinks(interpreter); err == nil {
+		interpreter = realPath
+	}
+	return getElfLibDirectoryOfLoader(interpreter, executable.class)
+}
+
+func getElfLibDirectoryOfLoader(loaderPath string, class elf.Class) string {
+	if loaderPath != "" {
+		directory := filepath.ToSlash(filepath.Dir(loaderPath))
+		directory = strings.TrimPrefix(strings.TrimPrefix(directory, "/usr"), "/")
+		if strings.HasPrefix(directory, "lib") {
+			return directory
+		}
+		if base := filepath.Base(directory); strings.HasPrefix(base, "lib") {
+			// prefixes like /nix/store/<hash>-glibc-2.40/lib
+			return base
+		}
+	}
+	// statically linked executables have no loader
+	if class == elf.ELFCLASS64 {
+		return "lib64"
+	}
+	return "lib"
+}
+
+func elfPlatform(machine elf.Machine) string {
+	switch machine {
+	case elf.EM_X86_64:
+		return "x86_64"
+	case elf.EM_386:
+		return "i686"
+	case elf.EM_AARCH64:
+		return "aarch64"
+	case elf.EM_ARM:
+		return "v7l"
+	case elf.EM_RISCV:
+		return "riscv64"
+	case elf.EM_PPC64:
+		return "power8"
+	case elf.EM_S390:
+		return "s390x"
+	default:
+		return strings.ToLower(strings.TrimPrefix(machine.String(), "EM_"))
+	}
+}
+
+// returns the trusted directories ld.so searches last, including the Debian multiarch ones
+func defaultElfLibraryDirectories(executable *elfObject) []string {
+	var directories []string
+	var triplet string
+	switch executable.machine {
+	case elf.EM_X86_64:
+		triplet = "x86_64-linux-gnu"
+	case elf.EM_386:
+		triplet = "i386-linux-gnu"
+	case elf.EM_AARCH64:
+		triplet = "aarch64-linux-gnu"
+	case elf.EM_ARM:
+		triplet = "arm-linux-gnueabihf"
+	case elf.EM_RISCV:
+		triplet = "riscv64-linux-gnu"
+	case elf.EM_PPC64:
+		triplet = "powerpc64le-linux-gnu"
+	case elf.EM_S390:
+		triplet = "s390x-linux-gnu"
+	}
+	if triplet != "" {
+		directories = append(directories, "/lib/"+triplet, "/usr/lib/"+triplet)
+	}
+	if executable.class == elf.ELFCLASS64 {
+		directories = append(directories, "/lib64", "/usr/lib64")
+	}
+	return append(directories, "/lib", "/usr/lib")
+}
+
+// returns the paths listed in /etc/ld.so.cache of a file system for a library name
+func lookupLdSoCache(fsys FileSystem, name string) []string {
+	if gLdSoCaches == nil {
+		gLdSoCaches = map[FileSystem]map[string][]string{}
+	}
+	ldSoCache, ok := gLdSoCaches[fsys]
+	if !ok {
+		// initialize static cache instance, a missing or broken cache is treated as empty like ld.so does
+		data, err := readFile(fsys, "/etc/ld.so.cache")
+		if err == nil {
+			ldSoCache = parseLdSoCache(data)
+		}
+		if ldSoCache == nil {
+			ldSoCache = map[string][]string{}
+		}
+		gLdSoCaches[fsys] = ldSoCache
+	}
+	return ldSoCache[name]
+}
+
+// parses the old ("ld.so-1.7.0") and new ("glibc-ld.so.cache1.1") ld.so.cache formats as written by ldconfig(8)
+func parseLdSoCache(data []byte) map[string][]string {
+	const oldMagic = "ld.so-1.7.0"
+	const newMagic = "glibc-ld.so.cache1.1"
+	byteOrder := binary.NativeEndian
+
+	cache := map[string][]string{}
+	cString := func(table []byte, offset uint32) string {
+		if int(offset) >= len(table) {
+			return ""
+		}
+		end := bytes.IndexByte(table[offset:], 0)
+		if end < 0 {
+			return ""
+		}
+		return string(table[offset : int(offset)+end])
+	}
+
+	newFormat := data
+	if bytes.HasPrefix(data, []byte(oldMagic)) {
+		// old header: magic padded to 12 bytes, entry count followed by 12 byte entries
+		if len(data) < 16 {
+			return nil
+		}
+		count := int(byteOrder.Uint32(data[12:16]))
+		entriesEnd := 16 + count*12
+		if entriesEnd > len(data) {
+			return nil
+		}
+		// the new format is appended to the old one, aligned to 8 bytes
+		newStart := (entriesEnd + 7) &^ 7
+		if newStart < len(data) && bytes.HasPrefix(data[newStart:], []byte(newMagic)) {
+			newFormat = data[newStart:]
+		} else {
+			// string offsets of the old format are relative to the end of the entries
+			stringTable := data[entriesEnd:]
+			for i := 0; i < count; i++ {
+				entry := data[16+i*12:]
+				key := cString(stringTable, byteOrder.Uint32(entry[4:8]))
+				value := cString(stringTable, byteOrder.Uint32(entry[8:12]))
+				if key != "" && value != "" {
+					cache[key] = append(cache[key], value)
+				}
+			}
+			return cache
+		}
+	}
+
+	if !bytes.HasPrefix(newFormat, []byte(newMagic)) || len(newFormat) < 48 {
+		return nil
+	}
+	// new header: magic, entry count, string table length, flags and padding (48 bytes) followed by 24 byte entries
+	count := int(byteOrder.Uint32(newFormat[20:24]))
+	if 48+count*24 > len(newFormat) {
+		return nil
+	}
+	for i := 0; i < count; i++ {
+		entry := newFormat[48+i*24:]
+		// string offsets of the new format are relative to its header
+		key := cString(newFormat, byteOrder.Uint32(entry[4:8]))
+		value := cString(newFormat, byteOrder.Uint32(entry[8:12]))
+		if key != "" && value != "" {
+			cache[key] = append(cache[key], value)
+		}
+	}
+	return cache
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseLdSoCache(t *testing.T) {
+	// ldconfig keeps libraries of the same name in the order of ld.so.conf
+	expected := map[string][]string{
+		"libbar.so.2": {"/opt/app/lib/libbar.so.2"},
+		"libfoo.so.1": {"/opt/app/lib/libfoo.so.1", "/usr/lib/x86_64-linux-gnu/libfoo.so.1"},
+	}
+	for _, format := range []string{"new", "old", "compat"} {
+		t.Run(format, func(t *testing.T) {
+			data, err := os.ReadFile("testdata/ld-so-cache/ld.so.cache-" + format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			cache := parseLdSoCache(data)
+			for name := range cache {
+				if !reflect.DeepEqual(cache[name], expected[name]) {
+					t.Errorf("%s: got %v, expected %v", name, cache[name], expected[name])
+				}
+			}
+			if len(cache) != len(expected) {
+				t.Errorf("got %d names, expected %d", len(cache), len(expected))
+			}
+		})
+	}
+}
+
+func TestParseLdSoCacheBroken(t *testing.T) {
+	data, err := os.ReadFile("testdata/ld-so-cache/ld.so.cache-new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, broken := range [][]byte{nil, []byte("glibc-ld.so.cache1.1"), data[:60], []byte("ld.so-1.7.0\x00\xff\xff\xff\x00")} {
+		if cache := parseLdSoCache(broken); len(cache) != 0 {
+			t.Errorf("got %v for a broken cache", cache)
+		}
+	}
+}
+
+func TestGetElfLibDirectoryOfLoader(t *testing.T) {
+	tests := []struct {
+		loader   string
+		class    elf.Class
+		expected string
+	}{
+		{"/usr/lib/x86_64-linux-gnu/ld-linux-x86-64.so.2", elf.ELFCLASS64, "lib/x86_64-linux-gnu"},
+		{"/lib/aarch64-linux-gnu/ld-linux-aarch64.so.1", elf.ELFCLASS64, "lib/aarch64-linux-gnu"},
+		{"/usr/lib64/ld-linux-x86-64.so.2", elf.ELFCLASS64, "lib64"},
+		{"/lib/ld-linux.so.2", elf.ELFCLASS32, "lib"},
+		{"/usr/lib/ld-linux-x86-64.so.2", elf.ELFCLASS64, "lib"},
+		{"/nix/store/4gk773fqcsv4fh2rfkhs9bgfih86fdq8-glibc-2.40-66/lib/ld-linux-x86-64.so.2", elf.ELFCLASS64, "lib"},
+		{"", elf.ELFCLASS64, "lib64"},
+		{"", elf.ELFCLASS32, "lib"},
+	}
+	for _, test := range tests {
+		if lib := getElfLibDirectoryOfLoader(test.loader, test.class); lib != test.expected {
+			t.Errorf("%s: got %s, expected %s", test.loader, lib, test.expected)
+		}
+	}
+}
+
+func TestResolveElfDependenciesRpathThroughRunpath(t *testing.T) {
+	root := t.TempDir()
+	for file, path := range map[string]string{
+		"exe":     "/app/bin/exe",
+		"libA.so": "/app/rpath/libA.so",
+		"libB.so": "/app/runpath/libB.so",
+		"libC.so": "/app/rpath/libC.so",
+	} {
+		data, err := os.ReadFile(filepath.Join("testdata/elf-resolver", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		path = filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// libA has a DT_RUNPATH, so the DT_RPATH of the executable must not find this copy of libB for it
+	if err := os.WriteFile(filepath.Join(root, "/app/rpath/libB.so"), nil, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	graph, err := resolveElfDependencies(rootFileSystem{root: root}, "/app/bin/exe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]LibraryDependency{
+		"/app/bin/exe":         {Name: "libA.so", Path: "/app/rpath/libA.so", NeededBy: "/app/bin/exe", ResolvedBy: "DT_RPATH"},
+		"/app/rpath/libA.so":   {Name: "libB.so", Path: "/app/runpath/libB.so", NeededBy: "/app/rpath/libA.so", ResolvedBy: "DT_RUNPATH"},
+		"/app/runpath/libB.so": {Name: "libC.so", Path: "/app/rpath/libC.so", NeededBy: "/app/runpath/libB.so", ResolvedBy: "DT_RPATH"},
+	}
+	for neededBy, dependency := range expected {
+		// the dynamic loader of the executable comes first and is not part of the root
+		if got := graph.Dependencies[neededBy]; len(got) == 0 || got[len(got)-1] != dependency {
+			t.Errorf("%s: got %+v, expected %+v", neededBy, got, dependency)
+		}
+	}
+	if len(graph.Unresolved) != 1 || graph.Unresolved[0].ResolvedBy != "PT_INTERP" {
+		t.Errorf("got unresolved %+v", graph.Unresolved)
+	}
+}
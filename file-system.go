@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// FileSystem gives access to the files of the analysed system, which is either the host itself or
+// a root directory of another system, e.g. an extracted root file system of a golden image
+type FileSystem interface {
+	Open(path string) (File, error)
+	Stat(path string) (os.FileInfo, error) // follows symlinks
+	EvalSymlinks(path string) (string, error)
+	ReadDir(path string) ([]os.DirEntry, error)
+}
+
+// File is an opened file of a FileSystem, binaries are parsed with random access
+type File interface {
+	io.Reader
+	io.ReaderAt
+	io.Seeker
+	io.Closer
+	Stat() (os.FileInfo, error)
+}
+
+// hostFileSystem is the file system of the running system
+type hostFileSystem struct{}
+
+func (hostFileSystem) Open(path string) (File, error) {
+	return os.Open(path)
+}
+
+func (hostFileSystem) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+func (hostFileSystem) EvalSymlinks(path string) (string, error) {
+	return filepath.EvalSymlinks(path)
+}
+
+func (hostFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	return os.ReadDir(path)
+}
+
+// rootFileSystem is a directory treated as the root of another system, like chroot(2) absolute
+// paths and symlinks are resolved relative to the root so they can't point back into the host
+type rootFileSystem struct {
+	root string
+}
+
+func (r rootFileSystem) Open(path string) (File, error) {
+	hostPath, err := r.hostPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(hostPath)
+}
+
+func (r rootFileSystem) Stat(path string) (os.FileInfo, error) {
+	hostPath, err := r.hostPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(hostPath)
+}
+
+func (r rootFileSystem) EvalSymlinks(path string) (string, error) {
+	return evalSymlinksInRoot(path,
+		func(path string) (os.FileInfo, error) {
+			return os.Lstat(filepath.Join(r.root, path))
+		},
+		func(path string) (string, error) {
+			return os.Readlink(filepath.Join(r.root, path))
+		})
+}
+
+func (r rootFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	hostPath, err := r.hostPath(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(hostPath)
+}
+
+// returns the path on the host of a path inside the root, with all symlinks resolved
+func (r rootFileSystem) hostPath(path string) (string, error) {
+	resolvedPath, err := r.EvalSymlinks(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(r.root, resolvedPath), nil
+}
+
+// resolves all symlinks of an absolute path like filepath.EvalSymlinks, but absolute symlinks and
+// ".." components are resolved relative to the root, so the resolved path never leaves the root
+func evalSymlinksInRoot(path string, lstat func(string) (os.FileInfo, error), readlink func(string) (string, error)) (string, error) {
+	// same limit as the Linux kernel (MAXSYMLINKS)
+	const maxSymlinks = 40
+
+	symlinks := 0
+	resolved := "/"
+	remaining := path
+	for remaining != "" {
+		var component string
+		component, remaining, _ = strings.Cut(remaining, "/")
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			// the parent of the root is the root itself
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, component)
+		fileInfo, err := lstat(next)
+		if err != nil {
+			return "", err
+		}
+		if fileInfo.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		symlinks++
+		if symlinks > maxSymlinks {
+			return "", fmt.Errorf("too many levels of symbolic links: %s", path)
+		}
+		target, err := readlink(next)
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(target, "/") {
+			resolved = "/"
+		}
+		remaining = target + "/" + remaining
+	}
+	return resolved, nil
+}
+
+// reads a whole file of a file system
+func readFile(fsys FileSystem, path string) ([]byte, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
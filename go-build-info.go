@@ -0,0 +1,74 @@
+package main
+
+import (
+	"debug/buildinfo"
+	"fmt"
+	"io"
+	"runtime/debug"
+)
+
+// GoBuildInfo holds the toolchain, modules and build settings embedded in Go binaries, an outdated
+// toolchain or dependency is a known vulnerability of the binary
+type GoBuildInfo struct {
+	GoVersion    string     `json:"go_version"` // e.g. go1.22.1
+	Path         string     `json:"path"`       // package path of the main package
+	MainModule   GoModule   `json:"main_module"`
+	Dependencies []GoModule `json:"dependencies"`
+	CgoEnabled   bool       `json:"cgo_enabled"`
+	Trimpath     bool       `json:"trimpath"`
+	GoArch       string     `json:"goarch"`
+	VcsRevision  string     `json:"vcs_revision"` // empty if built outside of a VCS checkout or with -buildvcs=false
+}
+
+// GoModule is a module of a Go binary with the version it was built with
+type GoModule struct {
+	Path        string    `json:"path"`
+	Version     string    `json:"version"` // (devel) for the main module built from a checkout
+	Sum         string    `json:"sum"`
+	Replacement *GoModule `json:"replacement,omitempty"` // module that replaced it by a replace directive
+}
+
+// reads the build info of a Go binary in ELF, PE or Mach-O format, returns an error for binaries
+// of other languages
+func getGoBuildInfo(reader io.ReaderAt) (*GoBuildInfo, error) {
+	info, err := buildinfo.Read(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Go build info: %v", err)
+	}
+
+	goBuildInfo := &GoBuildInfo{
+		GoVersion:    info.GoVersion,
+		Path:         info.Path,
+		MainModule:   newGoModule(&info.Main),
+		Dependencies: []GoModule{},
+	}
+	for _, dependency := range info.Deps {
+		goBuildInfo.Dependencies = append(goBuildInfo.Dependencies, newGoModule(dependency))
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "CGO_ENABLED":
+			goBuildInfo.CgoEnabled = setting.Value == "1"
+		case "-trimpath":
+			goBuildInfo.Trimpath = setting.Value == "true"
+		case "GOARCH":
+			goBuildInfo.GoArch = setting.Value
+		case "vcs.revision":
+			goBuildInfo.VcsRevision = setting.Value
+		}
+	}
+	return goBuildInfo, nil
+}
+
+func newGoModule(module *debug.Module) GoModule {
+	goModule := GoModule{
+		Path:    module.Path,
+		Version: module.Version,
+		Sum:     module.Sum,
+	}
+	if module.Replace != nil {
+		replacement := newGoModule(module.Replace)
+		goModule.Replacement = &replacement
+	}
+	return goModule
+}
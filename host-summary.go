@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"code.cloudfoundry.org/bytefmt" // to convert bytes into/from human-readable format
+	. "github.com/ahmetb/go-linq"   // LINQ for Go to manage data structure like its 2025
+)
+
+// HostSummary quantifies the attack-surface of the whole host, code that is loaded by multiple
+// processes like libc is only counted once
+type HostSummary struct {
+	UniqueExecutables              int                   `json:"unique_executables"`
+	UniqueExecutablesSizeInBytes   int64                 `json:"unique_executables_size_in_bytes"`
+	UniqueLibraries                int                   `json:"unique_libraries"`
+	UniqueLibrariesSizeInBytes     int64                 `json:"unique_libraries_size_in_bytes"`
+	SharedBaselineSizeInBytes      int64                 `json:"shared_baseline_size_in_bytes"` // code loaded by more than one process
+	PrivilegedProcesses            int                   `json:"privileged_processes"`
+	LibrariesOfPrivilegedProcesses []PrivilegedLibrary   `json:"libraries_of_privileged_processes"`
+	ProcessContributions           []ProcessContribution `json:"process_contributions"`
+}
+
+// PrivilegedLibrary describes how many privileged processes load a library
+type PrivilegedLibrary struct {
+	Path                string `json:"path"`
+	SizeInBytes         int64  `json:"size_in_bytes"`
+	PrivilegedProcesses int    `json:"privileged_processes"`
+	Processes           int    `json:"processes"`
+}
+
+// ProcessContribution is the attack-surface a process adds on top of the shared baseline, i.e. the
+// code no other process loads
+type ProcessContribution struct {
+	Pid              int32  `json:"pid"`
+	Name             string `json:"name"`
+	ExecutablePath   string `json:"path"`
+	AddedSizeInBytes int64  `json:"added_size_in_bytes"`
+}
+
+// code object (executable or library) loaded by one or more processes
+type loadedCode struct {
+	path                string
+	sizeInBytes         int64
+	isExecutable        bool
+	isLibrary           bool
+	processes           int
+	privilegedProcesses int
+}
+
+// calculates the attack-surface of the host with each executable and library counted once
+func calculateHostSummary(processInfos []ProcessInfo) HostSummary {
+	summary := HostSummary{}
+
+	codeByPath := map[string]*loadedCode{}
+	addCode := func(key string, path string, sizeInBytes int64, isExecutable bool, isPrivileged bool) {
+		code, ok := codeByPath[key]
+		if !ok {
+			code = &loadedCode{path: path, sizeInBytes: sizeInBytes}
+			codeByPath[key] = code
+		}
+		// a library may be analysed on its own as well, e.g. by an offline scan
+		code.isExecutable = code.isExecutable || isExecutable
+		code.isLibrary = code.isLibrary || !isExecutable
+		code.processes++
+		if isPrivileged {
+			code.privilegedProcesses++
+		}
+	}
+	for _, info := range processInfos {
+		isPrivileged := isPrivilegedProcess(info)
+		if isPrivileged {
+			summary.PrivilegedProcesses++
+		}
+		addCode(getLoadedCodeKey(info, info.ExecutablePath), info.ExecutablePath, info.ExecutableSizeInBytes+info.ExecutablePayloadSizeInBytes, true, isPrivileged)
+		for _, library := range info.Libraries {
+			addCode(getLoadedCodeKey(info, library.Path), library.Path, library.SizeInBytes, false, isPrivileged)
+		}
+	}
+
+	var privilegedLibraries []PrivilegedLibrary
+	for _, code := range codeByPath {
+		if code.isExecutable {
+			summary.UniqueExecutables++
+			summary.UniqueExecutablesSizeInBytes += code.sizeInBytes
+		} else {
+			summary.UniqueLibraries++
+			summary.UniqueLibrariesSizeInBytes += code.sizeInBytes
+		}
+		if code.isLibrary && code.privilegedProcesses > 0 {
+			privilegedLibraries = append(privilegedLibraries, PrivilegedLibrary{
+				Path:                code.path,
+				SizeInBytes:         code.sizeInBytes,
+				PrivilegedProcesses: code.privilegedProcesses,
+				Processes:           code.processes,
+			})
+		}
+		if code.processes > 1 {
+			summary.SharedBaselineSizeInBytes += code.sizeInBytes
+		}
+	}
+
+	// use LINQ to sort by the number of privileged processes, bigger libraries first on ties
+	From(privilegedLibraries).
+		OrderByDescendingT(func(l PrivilegedLibrary) int {
+			return l.PrivilegedProcesses
+		}).
+		ThenByDescendingT(func(l PrivilegedLibrary) int64 {
+			return l.SizeInBytes
+		}).
+		ThenByT(func(l PrivilegedLibrary) string {
+			return l.Path
+		}).
+		ToSlice(&summary.LibrariesOfPrivilegedProcesses)
+
+	var contributions []ProcessContribution
+	for _, info := range processInfos {
+		contribution := ProcessContribution{
+			Pid:            info.Pid,
+			Name:           info.Name,
+			ExecutablePath: info.ExecutablePath,
+		}
+		seen := map[string]bool{}
+		paths := []string{info.ExecutablePath}
+		for _, library := range info.Libraries {
+			paths = append(paths, library.Path)
+		}
+		for _, path := range paths {
+			key := getLoadedCodeKey(info, path)
+			code := codeByPath[key]
+			if seen[key] || code.processes > 1 {
+				continue
+			}
+			seen[key] = true
+			contribution.AddedSizeInBytes += code.sizeInBytes
+		}
+		contributions = append(contributions, contribution)
+	}
+	From(contributions).
+		OrderByDescendingT(func(c ProcessContribution) int64 {
+			return c.AddedSizeInBytes
+		}).
+		ToSlice(&summary.ProcessContributions)
+
+	return summary
+}
+
+// returns the key of an executable or library, the same path in the mount namespace of a container
+// is a different file than on the host
+func getLoadedCodeKey(info ProcessInfo, path string) string {
+	if info.Container.MountNamespace == 0 || info.Container.SharesHostMountNamespace {
+		return path
+	}
+	return fmt.Sprintf("mnt:[%d]:%s", info.Container.MountNamespace, path)
+}
+
+// writes the host summary, the lists are limited to the top entries
+func printTextHostSummary(writer io.Writer, summary HostSummary) {
+	const maxEntries = 10
+
+	fmt.Fprintln(writer)
+	fmt.Fprintln(writer, "Host summary (each executable and library counted once):")
+	fmt.Fprintf(writer, "Unique executables: %d (%s code) | Unique libraries: %d (%s code) | Shared baseline: %s code loaded by more than one process\n",
+		summary.UniqueExecutables, bytefmt.ByteSize(uint64(summary.UniqueExecutablesSizeInBytes)),
+		summary.UniqueLibraries, bytefmt.ByteSize(uint64(summary.UniqueLibrariesSizeInBytes)),
+		bytefmt.ByteSize(uint64(summary.SharedBaselineSizeInBytes)))
+
+	fmt.Fprintf(writer, "Libraries loaded by most privileged processes (%d privileged processes):\n", summary.PrivilegedProcesses)
+	for i, library := range summary.LibrariesOfPrivilegedProcesses {
+		if i == maxEntries {
+			break
+		}
+		fmt.Fprintf(writer, "Privileged: %4d | Total: %4d | Size: %7s | Library Path: %s\n",
+			library.PrivilegedProcesses, library.Processes,
+			bytefmt.ByteSize(uint64(library.SizeInBytes)), library.Path)
+	}
+
+	fmt.Fprintln(writer, "Attack-surface added on top of the shared baseline:")
+	for i, contribution := range summary.ProcessContributions {
+		if i == maxEntries {
+			break
+		}
+		fmt.Fprintf(writer, "PID: %6d | Added: %7s | Name: %s | Executable Path: %s\n",
+			contribution.Pid, bytefmt.ByteSize(uint64(contribution.AddedSizeInBytes)),
+			contribution.Name, contribution.ExecutablePath)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCalculateHostSummary(t *testing.T) {
+	libc := LibraryInfo{Path: "/usr/lib/x86_64-linux-gnu/libc.so.6", Origin: "linked", SizeInBytes: 50}
+	// nginx runs as root, curl as a user
+	nginx := ProcessInfo{Pid: 1, Name: "nginx", ExecutablePath: "/usr/sbin/nginx", ExecutableSizeInBytes: 100,
+		Libraries: []LibraryInfo{libc, {Path: "/usr/lib/x86_64-linux-gnu/libssl.so.3", Origin: "linked", SizeInBytes: 30}}}
+	curl := ProcessInfo{Pid: 2, Name: "curl", ExecutablePath: "/usr/bin/curl", ExecutableSizeInBytes: 20, UserId: 1000,
+		Libraries: []LibraryInfo{libc, {Path: "/usr/lib/x86_64-linux-gnu/libcurl.so.4", Origin: "linked", SizeInBytes: 40}}}
+	// the same path in the mount namespace of a container is a different file
+	containerCurl := curl
+	containerCurl.Pid = 3
+	containerCurl.Container = ContainerInfo{ContainerId: "4f1c9a", MountNamespace: 4026532290}
+
+	tests := []struct {
+		name         string
+		processInfos []ProcessInfo
+		expected     HostSummary
+	}{
+		{"no processes", nil, HostSummary{}},
+		{"libraries shared by processes", []ProcessInfo{nginx, curl}, HostSummary{
+			UniqueExecutables:            2,
+			UniqueExecutablesSizeInBytes: 120,
+			UniqueLibraries:              3,
+			UniqueLibrariesSizeInBytes:   120,
+			SharedBaselineSizeInBytes:    50,
+			PrivilegedProcesses:          1,
+			LibrariesOfPrivilegedProcesses: []PrivilegedLibrary{
+				{Path: libc.Path, SizeInBytes: 50, PrivilegedProcesses: 1, Processes: 2},
+				{Path: "/usr/lib/x86_64-linux-gnu/libssl.so.3", SizeInBytes: 30, PrivilegedProcesses: 1, Processes: 1},
+			},
+			ProcessContributions: []ProcessContribution{
+				{Pid: 1, Name: "nginx", ExecutablePath: "/usr/sbin/nginx", AddedSizeInBytes: 130},
+				{Pid: 2, Name: "curl", ExecutablePath: "/usr/bin/curl", AddedSizeInBytes: 60},
+			},
+		}},
+		{"executable run twice", []ProcessInfo{curl, curl}, HostSummary{
+			UniqueExecutables:            1,
+			UniqueExecutablesSizeInBytes: 20,
+			UniqueLibraries:              2,
+			UniqueLibrariesSizeInBytes:   90,
+			SharedBaselineSizeInBytes:    110,
+			ProcessContributions: []ProcessContribution{
+				{Pid: 2, Name: "curl", ExecutablePath: "/usr/bin/curl"},
+				{Pid: 2, Name: "curl", ExecutablePath: "/usr/bin/curl"},
+			},
+		}},
+		{"same paths in a container", []ProcessInfo{curl, containerCurl}, HostSummary{
+			UniqueExecutables:            2,
+			UniqueExecutablesSizeInBytes: 40,
+			UniqueLibraries:              4,
+			UniqueLibrariesSizeInBytes:   180,
+			ProcessContributions: []ProcessContribution{
+				{Pid: 2, Name: "curl", ExecutablePath: "/usr/bin/curl", AddedSizeInBytes: 110},
+				{Pid: 3, Name: "curl", ExecutablePath: "/usr/bin/curl", AddedSizeInBytes: 110},
+			},
+		}},
+	}
+	for _, test := range tests {
+		summary := calculateHostSummary(test.processInfos)
+		// empty lists are compared as nil
+		if len(summary.LibrariesOfPrivilegedProcesses) == 0 {
+			summary.LibrariesOfPrivilegedProcesses = nil
+		}
+		if len(summary.ProcessContributions) == 0 {
+			summary.ProcessContributions = nil
+		}
+		if !reflect.DeepEqual(summary, test.expected) {
+			t.Errorf("%s: got %+v, expected %+v", test.name, summary, test.expected)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "github.com/ahmetb/go-linq" // LINQ for Go to manage data structure like its 2025
+)
+
+// imageFileSystem is the root file system of a container image with all layers applied in memory,
+// only the metadata is kept, file contents are read from their layer when a file gets opened
+type imageFileSystem struct {
+	layers   []imageLayer
+	entries  map[string]*imageEntry // by absolute path
+	contents map[imageContent][]byte
+}
+
+// imageLayer is the tar of a layer, the contents of its files are read at their offset in place or,
+// if the layer is compressed, by decompressing the layer again up to the file
+type imageLayer struct {
+	tar   io.ReaderAt                   // nil for layers that can only be read as stream
+	open  func() (io.ReadCloser, error) // opens the blob of a layer that is read as stream again
+	close func() error
+}
+
+// imageContent is the content of a file in the tar of a layer, hard links share it
+type imageContent struct {
+	layer  int
+	offset int64
+}
+
+// imageEntry is a file, directory or symlink of an image, it implements os.FileInfo
+type imageEntry struct {
+	name       string
+	size       int64
+	mode       os.FileMode
+	modTime    time.Time
+	linkTarget string
+	layer      int   // layer that contains the content
+	offset     int64 // offset of the content in the tar of the layer
+}
+
+func (e *imageEntry) Name() string       { return e.name }
+func (e *imageEntry) Size() int64        { return e.size }
+func (e *imageEntry) Mode() os.FileMode  { return e.mode }
+func (e *imageEntry) ModTime() time.Time { return e.modTime }
+func (e *imageEntry) IsDir() bool        { return e.mode.IsDir() }
+func (e *imageEntry) Sys() any           { return nil }
+
+// imageFile is an opened file of an image, the content is read from the tar of its layer
+type imageFile struct {
+	*io.SectionReader
+	entry *imageEntry
+}
+
+func (f imageFile) Close() error               { return nil }
+func (f imageFile) Stat() (os.FileInfo, error) { return f.entry, nil }
+
+// prefixes of the tar entries that mark files of lower layers as deleted, see the OCI image spec
+const (
+	whiteoutPrefix = ".wh."
+	whiteoutOpaque = ".wh..wh..opq"
+)
+
+// applies the layers of an image in order, starting with the base layer
+func newImageFileSystem(layers []func() (io.ReadCloser, error)) (*imageFileSystem, error) {
+	fsys := &imageFileSystem{
+		entries:  map[string]*imageEntry{"/": {name: "/", mode: os.ModeDir | 0o755}},
+		contents: map[imageContent][]byte{},
+	}
+	for i, layer := range layers {
+		if err := fsys.applyLayer(i, layer); err != nil {
+			fsys.Close()
+			return nil, fmt.Errorf("failed to apply layer %d: %v", i, err)
+		}
+	}
+	return fsys, nil
+}
+
+// closes the tars of all layers that are read in place
+func (fsys *imageFileSystem) Close() error {
+	var err error
+	for _, layer := range fsys.layers {
+		if layer.close == nil {
+			continue
+		}
+		if closeErr := layer.close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	fsys.layers = nil
+	fsys.contents = nil
+	return err
+}
+
+func (fsys *imageFileSystem) applyLayer(layer int, open func() (io.ReadCloser, error)) error {
+	blob, err := open()
+	if err != nil {
+		return err
+	}
+	reader, compressed, err := openLayerTar(blob)
+	if err != nil {
+		blob.Close()
+		return err
+	}
+	if tar, ok := blob.(io.ReaderAt); ok && !compressed {
+		// files of extracted image layouts and tarballs are read in place
+		fsys.layers = append(fsys.layers, imageLayer{tar: tar, close: blob.Close})
+	} else {
+		// nothing is spooled to disk, the layer is decompressed again when one of its files gets opened
+		defer blob.Close()
+		fsys.layers = append(fsys.layers, imageLayer{open: open})
+	}
+
+	// tar.Reader reads the headers block by block, so the position of the underlying reader is the
+	// offset of the content after each header
+	counter := &countingReader{reader: reader}
+	offsets := map[*tar.Header]int64{}
+
+	// whiteouts only delete files of lower layers, so they are applied before the files of the
+	// layer itself, regardless of their order in the tar
+	var headers []*tar.Header
+	tarReader := tar.NewReader(counter)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		offsets[header] = counter.count
+		path := filepath.Clean("/" + header.Name)
+		directory, name := filepath.Split(path)
+		switch {
+		case name == whiteoutOpaque:
+			fsys.removeChildren(filepath.Clean(directory))
+		case strings.HasPrefix(name, whiteoutPrefix):
+			deletedPath := filepath.Join(directory, strings.TrimPrefix(name, whiteoutPrefix))
+			fsys.removeChildren(deletedPath)
+			delete(fsys.entries, deletedPath)
+		default:
+			headers = append(headers, header)
+		}
+	}
+
+	for _, header := range headers {
+		path := filepath.Clean("/" + header.Name)
+		if path == "/" {
+			continue
+		}
+		entry := &imageEntry{
+			name:    filepath.Base(path),
+			size:    header.Size,
+			mode:    header.FileInfo().Mode(),
+			modTime: header.ModTime,
+			layer:   layer,
+			offset:  offsets[header],
+		}
+		switch header.Typeflag {
+		case tar.TypeReg, tar.TypeDir:
+		case tar.TypeSymlink:
+			entry.linkTarget = header.Linkname
+		case tar.TypeLink:
+			// hard links share the content of a file of the same or a lower layer
+			target, ok := fsys.entries[filepath.Clean("/"+header.Linkname)]
+			if !ok || !target.mode.IsRegular() {
+				continue
+			}
+			entry.size, entry.layer, entry.offset = target.size, target.layer, target.offset
+			entry.mode = target.mode
+		default:
+			// devices, fifos etc. contain no code
+			continue
+		}
+
+		if existing, ok := fsys.entries[path]; ok && existing.IsDir() && !entry.IsDir() {
+			fsys.removeChildren(path)
+		}
+		fsys.addParentDirectories(path)
+		fsys.entries[path] = entry
+	}
+	return nil
+}
+
+// opens the tar of a layer, layers may be compressed with gzip or not at all
+func openLayerTar(blob io.Reader) (io.Reader, bool, error) {
+	bufferedBlob := bufio.NewReader(blob)
+	magic, _ := bufferedBlob.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		gzipReader, err := gzip.NewReader(bufferedBlob)
+		return gzipReader, true, err
+	case bytes.Equal(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return nil, true, fmt.Errorf("zstd compressed layers are not supported")
+	}
+	return bufferedBlob, false, nil
+}
+
+// reads the content of a file of a layer that can only be read as stream, the layer is decompressed
+// up to the file, which is kept in memory as long as the file system is open, e.g. for libraries
+// that are opened again and again
+func (fsys *imageFileSystem) readLayerContent(entry *imageEntry) ([]byte, error) {
+	key := imageContent{layer: entry.layer, offset: entry.offset}
+	if content, ok := fsys.contents[key]; ok {
+		return content, nil
+	}
+	blob, err := fsys.layers[entry.layer].open()
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+	reader, _, err := openLayerTar(blob)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, reader, entry.offset); err != nil {
+		return nil, fmt.Errorf("failed to decompress layer %d: %v", entry.layer, err)
+	}
+	content := make([]byte, entry.size)
+	if _, err := io.ReadFull(reader, content); err != nil {
+		return nil, fmt.Errorf("failed to decompress layer %d: %v", entry.layer, err)
+	}
+	fsys.contents[key] = content
+	return content, nil
+}
+
+// removes all entries below a directory
+func (fsys *imageFileSystem) removeChildren(directory string) {
+	prefix := strings.TrimSuffix(directory, "/") + "/"
+	for path := range fsys.entries {
+		if strings.HasPrefix(path, prefix) {
+			delete(fsys.entries, path)
+		}
+	}
+}
+
+// layers don't necessarily contain entries for all directories of their files
+func (fsys *imageFileSystem) addParentDirectories(path string) {
+	for directory := filepath.Dir(path); directory != "/"; directory = filepath.Dir(directory) {
+		if existing, ok := fsys.entries[directory]; ok && existing.IsDir() {
+			return
+		}
+		fsys.entries[directory] = &imageEntry{name: filepath.Base(directory), mode: os.ModeDir | 0o755}
+	}
+}
+
+func (fsys *imageFileSystem) Open(path string) (File, error) {
+	entry, _, err := fsys.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.mode.IsRegular() {
+		return nil, &os.PathError{Op: "open", Path: path, Err: fmt.Errorf("not a regular file")}
+	}
+	if tar := fsys.layers[entry.layer].tar; tar != nil {
+		return imageFile{SectionReader: io.NewSectionReader(tar, entry.offset, entry.size), entry: entry}, nil
+	}
+	content, err := fsys.readLayerContent(entry)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return imageFile{SectionReader: io.NewSectionReader(bytes.NewReader(content), 0, entry.size), entry: entry}, nil
+}
+
+func (fsys *imageFileSystem) Stat(path string) (os.FileInfo, error) {
+	entry, _, err := fsys.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (fsys *imageFileSystem) EvalSymlinks(path string) (string, error) {
+	return evalSymlinksInRoot(path, fsys.lstat,
+		func(path string) (string, error) {
+			entry, err := fsys.lstat(path)
+			if err != nil {
+				return "", err
+			}
+			return entry.(*imageEntry).linkTarget, nil
+		})
+}
+
+func (fsys *imageFileSystem) ReadDir(path string) ([]os.DirEntry, error) {
+	entry, resolvedPath, err := fsys.resolve(path)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: path, Err: fmt.Errorf("not a directory")}
+	}
+	var entries []os.DirEntry
+	for childPath, child := range fsys.entries {
+		if childPath != "/" && filepath.Dir(childPath) == resolvedPath {
+			entries = append(entries, fs.FileInfoToDirEntry(child))
+		}
+	}
+	// sorted by name like os.ReadDir
+	var sortedEntries []os.DirEntry
+	From(entries).
+		OrderByT(func(e os.DirEntry) string {
+			return e.Name()
+		}).
+		ToSlice(&sortedEntries)
+	return sortedEntries, nil
+}
+
+func (fsys *imageFileSystem) lstat(path string) (os.FileInfo, error) {
+	entry, ok := fsys.entries[filepath.Clean("/"+path)]
+	if !ok {
+		return nil, &os.PathError{Op: "lstat", Path: path, Err: os.ErrNotExist}
+	}
+	return entry, nil
+}
+
+// returns the entry of a path with all symlinks resolved
+func (fsys *imageFileSystem) resolve(path string) (*imageEntry, string, error) {
+	resolvedPath, err := fsys.EvalSymlinks(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return fsys.entries[resolvedPath], resolvedPath, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+)
+
+// testTarEntry is a file, symlink, hard link or whiteout of a layer built by a test
+type testTarEntry struct {
+	name     string
+	content  string
+	linkname string
+	typeflag byte
+	mode     int64
+}
+
+func buildTestLayer(t *testing.T, entries []testTarEntry, compress bool) []byte {
+	var buffer bytes.Buffer
+	var writer io.Writer = &buffer
+	var gzipWriter *gzip.Writer
+	if compress {
+		gzipWriter = gzip.NewWriter(&buffer)
+		writer = gzipWriter
+	}
+	tarWriter := tar.NewWriter(writer)
+	for _, entry := range entries {
+		header := &tar.Header{Name: entry.name, Linkname: entry.linkname, Typeflag: entry.typeflag, Mode: entry.mode}
+		if header.Typeflag == 0 {
+			header.Typeflag = tar.TypeReg
+		}
+		if header.Mode == 0 {
+			header.Mode = 0o755
+		}
+		if header.Typeflag == tar.TypeReg {
+			header.Size = int64(len(entry.content))
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tarWriter.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tarWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gzipWriter != nil {
+		if err := gzipWriter.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buffer.Bytes()
+}
+
+func TestImageFileSystem(t *testing.T) {
+	// a compressed base layer, an uncompressed layer read in place like those of OCI image layouts
+	// and an uncompressed layer that can only be read as stream
+	base := buildTestLayer(t, []testTarEntry{
+		{name: "bin/", typeflag: tar.TypeDir},
+		{name: "bin/busybox", content: "busybox binary"},
+		{name: "bin/sh", linkname: "busybox", typeflag: tar.TypeSymlink},
+		{name: "etc/passwd", content: "root:x:0:0:root:/root:/bin/sh\n", mode: 0o644},
+		{name: "opt/old/tool", content: "deleted by the next layer"},
+	}, true)
+	application := buildTestLayer(t, []testTarEntry{
+		{name: "opt/.wh.old"},
+		{name: "bin/true", linkname: "bin/busybox", typeflag: tar.TypeLink},
+		{name: "usr/sbin/nginx", content: "nginx binary"},
+	}, false)
+	configuration := buildTestLayer(t, []testTarEntry{
+		{name: "etc/nginx/nginx.conf", content: "daemon off;\n", mode: 0o644},
+	}, false)
+	// layers that are read as stream are decompressed again instead of being spooled to disk
+	temporaryDirectory := t.TempDir()
+	t.Setenv("TMPDIR", temporaryDirectory)
+	baseOpened := 0
+	fsys, err := newImageFileSystem([]func() (io.ReadCloser, error){
+		func() (io.ReadCloser, error) {
+			baseOpened++
+			return io.NopCloser(bytes.NewReader(base)), nil
+		},
+		func() (io.ReadCloser, error) {
+			return sectionReadCloser{io.NewSectionReader(bytes.NewReader(application), 0, int64(len(application)))}, nil
+		},
+		func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(configuration)), nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsys.Close()
+
+	// files are read in any order, regardless of their layer
+	files := map[string]string{
+		"/usr/sbin/nginx":          "nginx binary",
+		"/bin/sh":                  "busybox binary",
+		"/etc/nginx/nginx.conf":    "daemon off;\n",
+		"/bin/true":                "busybox binary",
+		"/etc/passwd":              "root:x:0:0:root:/root:/bin/sh\n",
+		"/usr/sbin/../../bin/true": "busybox binary",
+		"/usr/sbin/apache2":        "",
+	}
+	for path, expected := range files {
+		content, err := readFile(fsys, path)
+		if expected == "" {
+			if err == nil {
+				t.Errorf("%s: no error", path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+		} else if string(content) != expected {
+			t.Errorf("%s: got %q, expected %q", path, content, expected)
+		}
+	}
+
+	// files can be read at any offset
+	file, err := fsys.Open("/bin/busybox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	word := make([]byte, 6)
+	if _, err := file.ReadAt(word, 8); err != nil || string(word) != "binary" {
+		t.Errorf("got %q at offset 8: %v", word, err)
+	}
+	file.Close()
+
+	// the content of a file is decompressed once, hard links share it
+	if baseOpened != 3 {
+		t.Errorf("base layer opened %d times, expected 3", baseOpened)
+	}
+	if spooled, _ := os.ReadDir(temporaryDirectory); len(spooled) != 0 {
+		t.Errorf("got temporary files %v", spooled)
+	}
+
+	if _, err := fsys.Stat("/opt/old/tool"); err == nil {
+		t.Errorf("whiteout did not delete /opt/old")
+	}
+	if _, err := fsys.Stat("/opt"); err != nil {
+		t.Errorf("whiteout deleted the parent directory: %v", err)
+	}
+}
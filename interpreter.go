@@ -0,0 +1,379 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// roles of interpreter options
+const (
+	optionValue       = "value"       // followed by a value, e.g. python -W ignore
+	optionInlineCode  = "inline code" // followed by the code of the application, e.g. python -c
+	optionModule      = "module"      // followed by the module or main class of the application, e.g. python -m
+	optionApplication = "application" // followed by the file of the application, e.g. java -jar
+	optionClassPath   = "class path"  // followed by the class path of the application, e.g. java -cp
+)
+
+// interpreter of a scripting language or virtual machine, its processes run an application given on
+// their command line, which is written in another language than the interpreter itself (mostly C)
+type interpreter struct {
+	name               string
+	language           string            // language of the applications
+	executable         *regexp.Regexp    // names of the interpreter executable, e.g. python3.11
+	options            map[string]string // options with their role, options without role are flags
+	joinedShortOptions bool              // short options may be joined with their value, e.g. -Wignore
+	flags              string            // letters of the short flags that can be clustered, e.g. -Im
+	mainClass          bool              // the application is a main class found on the class path
+}
+
+var interpreters = []interpreter{
+	{
+		name:       "python",
+		language:   "Python",
+		executable: regexp.MustCompile(`^(python|pypy)[\d.]*$`),
+		options: map[string]string{
+			"-c": optionInlineCode, "-m": optionModule, "-W": optionValue, "-X": optionValue,
+			"--check-hash-based-pycs": optionValue,
+		},
+		joinedShortOptions: true,
+		flags:              "bBdEhiIOPqRsSuvVx",
+	},
+	{
+		name:       "node",
+		language:   "JavaScript/TypeScript",
+		executable: regexp.MustCompile(`^(node|nodejs)$`),
+		options: map[string]string{
+			"-e": optionInlineCode, "--eval": optionInlineCode, "-p": optionInlineCode, "--print": optionInlineCode,
+			"-r": optionValue, "--require": optionValue, "--import": optionValue, "--loader": optionValue,
+			"--experimental-loader": optionValue, "-C": optionValue, "--conditions": optionValue,
+			"--inspect-port": optionValue, "--title": optionValue,
+		},
+	},
+	{
+		name:       "ruby",
+		language:   "Ruby",
+		executable: regexp.MustCompile(`^ruby[\d.]*$`),
+		options: map[string]string{
+			"-e": optionInlineCode, "-I": optionValue, "-r": optionValue, "-C": optionValue, "-E": optionValue,
+			"--encoding": optionValue,
+		},
+		joinedShortOptions: true,
+		flags:              "acdhlnpsSvwy",
+	},
+	{
+		name:               "perl",
+		language:           "Perl",
+		executable:         regexp.MustCompile(`^perl[\d.]*$`),
+		options:            map[string]string{"-e": optionInlineCode, "-E": optionInlineCode},
+		joinedShortOptions: true,
+		flags:              "acdlnpsStTuUvwWX",
+	},
+	{
+		name:       "java",
+		language:   "Java",
+		executable: regexp.MustCompile(`^java$`),
+		options: map[string]string{
+			"-jar": optionApplication, "-m": optionModule, "--module": optionModule,
+			"-cp": optionClassPath, "-classpath": optionClassPath, "--class-path": optionClassPath,
+			"-p": optionValue, "--module-path": optionValue, "--upgrade-module-path": optionValue,
+			"--add-modules": optionValue, "--add-opens": optionValue, "--add-exports": optionValue,
+			"--add-reads": optionValue, "--patch-module": optionValue, "--limit-modules": optionValue,
+		},
+		mainClass: true,
+	},
+}
+
+// InterpretedApplication is the application an interpreter process runs
+type InterpretedApplication struct {
+	Interpreter string   `json:"interpreter"` // python, node, ruby, perl or java
+	Path        string   `json:"path"`        // script or jar, empty for modules, main classes, inline code and interactive sessions
+	Module      string   `json:"module"`      // module of python -m, main class or module of java
+	InlineCode  bool     `json:"inline_code"` // code given on the command line or stdin, e.g. python -c
+	ClassPath   []string `json:"class_path"`  // java only
+}
+
+var pythonVersionPattern = regexp.MustCompile(`^python(\d+\.\d+)`)
+var pythonSystemSitePackagesPattern = regexp.MustCompile(`(?m)^include-system-site-packages\s*=\s*true`)
+
+// returns the interpreter of an executable, nil for other executables
+func getInterpreter(executablePath string) *interpreter {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(executablePath)), ".exe")
+	for i := range interpreters {
+		if interpreters[i].executable.MatchString(name) {
+			return &interpreters[i]
+		}
+	}
+	return nil
+}
+
+// returns the application on the command line of an interpreter process, e.g. app.py of
+// `python3 -W ignore app.py --port 80`, paths are relative to the working directory of the process
+func (i interpreter) parseCommandLine(args []string, cwd string) InterpretedApplication {
+	application := InterpretedApplication{Interpreter: i.name, ClassPath: []string{}}
+	for index := 1; index < len(args); index++ {
+		arg := args[index]
+		option, value, hasValue := arg, "", false
+		if _, known := i.options[arg]; !known {
+			switch {
+			case strings.HasPrefix(arg, "--"):
+				option, value, hasValue = strings.Cut(arg, "=")
+			case i.joinedShortOptions && len(arg) > 2 && arg[0] == '-':
+				_, isJoined := i.options[arg[:2]]
+				clusterOption := "-" + arg[len(arg)-1:]
+				if _, endsWithOption := i.options[clusterOption]; !isJoined && endsWithOption && i.isFlagCluster(arg[1:len(arg)-1]) {
+					// clustered flags ending with an option, e.g. perl -lne 'print' or python3 -Im pip
+					option = clusterOption
+				} else {
+					option, value, hasValue = arg[:2], arg[2:], true
+				}
+			}
+		}
+		role, known := i.options[option]
+		if known && !hasValue {
+			if index+1 >= len(args) {
+				break
+			}
+			index++
+			value = args[index]
+		}
+
+		switch {
+		case known && role == optionInlineCode:
+			application.InlineCode = true
+			return application
+		case known && role == optionModule:
+			application.Module = value
+			return application
+		case known && role == optionApplication:
+			application.Path = resolveProcessPath(value, cwd)
+			return application
+		case known && role == optionClassPath:
+			application.ClassPath = nil
+			for _, entry := range filepath.SplitList(value) {
+				application.ClassPath = append(application.ClassPath, resolveProcessPath(entry, cwd))
+			}
+		case known:
+		case arg == "-":
+			// code read from stdin
+			application.InlineCode = true
+			return application
+		case arg == "--":
+			// the application follows the end of the options
+			if index+1 < len(args) {
+				application.Path = resolveProcessPath(args[index+1], cwd)
+			}
+			return application
+		case strings.HasPrefix(arg, "-"):
+			// flags, e.g. python -u or java -Xmx1g
+		case i.mainClass:
+			application.Module = arg
+			return application
+		default:
+			// the first argument that is not an option is the script, its arguments follow
+			application.Path = resolveProcessPath(arg, cwd)
+			return application
+		}
+	}
+	return application
+}
+
+// reports if all letters are flags of the interpreter, e.g. ln of perl -lne
+func (i interpreter) isFlagCluster(letters string) bool {
+	for _, letter := range letters {
+		if !strings.ContainsRune(i.flags, letter) {
+			return false
+		}
+	}
+	return true
+}
+
+// describes the application of an interpreter process as language evidence
+func (application InterpretedApplication) describe(language string, name string) string {
+	switch {
+	case application.Path != "":
+		return fmt.Sprintf("Found %s application %s in commandline of %s", language, application.Path, name)
+	case application.Module != "":
+		return fmt.Sprintf("Found %s module %s in commandline of %s", language, application.Module, name)
+	case application.InlineCode:
+		return fmt.Sprintf("Found inline %s code in commandline of %s", language, name)
+	}
+	return fmt.Sprintf("Found interactive %s interpreter %s", language, name)
+}
+
+// analyses the application of an interpreter process instead of the interpreter itself and returns
+// its code, the directory of the application and the packages it can import, e.g. site-packages or
+// node_modules
+func analyseInterpretedApplication(fsys FileSystem, info *ProcessInfo, interpreter interpreter, application InterpretedApplication,
+	args []string, cwd string, environment []string) []LibraryInfo {
+	var languageInfo BinaryLanguageInfo
+	// the interpreter runs code of its language, but the name of a script or jar is no proof of it
+	languageInfo.addEvidence(evidenceCommandLine, application.describe(interpreter.language, info.Name), interpreter.language)
+	languageInfo = determineMostLikelyLanguage(languageInfo)
+	info.DetectedLanguage = languageInfo.MostLikelyLanguage
+	info.LanguageConfidence = languageInfo.Confidence
+	info.LanguageEvidence = languageInfo.Evidence
+	info.WeightedLanguageEvidence = languageInfo.WeightedEvidence
+	info.InterpretedApplication = &application
+
+	var code []LibraryInfo
+	addCode := func(path string, origin string) {
+		if fileInfo, err := fsys.Stat(path); err != nil || (origin == "packages" && !fileInfo.IsDir()) {
+			return
+		}
+		for _, c := range code {
+			if c.Path == path {
+				return
+			}
+		}
+		code = append(code, LibraryInfo{Path: path, Origin: origin})
+	}
+	getEnvironmentPaths := func(names ...string) []string {
+		var paths []string
+		for _, variable := range environment {
+			for _, name := range names {
+				if value, found := strings.CutPrefix(variable, name+"="); found && value != "" {
+					paths = append(paths, filepath.SplitList(value)...)
+				}
+			}
+		}
+		return paths
+	}
+
+	// the directory of a script is only the application if it is the root of a project, a script in
+	// e.g. /usr/bin or /tmp would count all files next to it otherwise
+	if fileInfo, err := fsys.Stat(application.Path); application.Path != "" && err == nil {
+		applicationDirectory := filepath.Dir(application.Path)
+		switch {
+		case fileInfo.IsDir():
+			// e.g. python app/ with app/__main__.py
+			addCode(application.Path, "application")
+		case interpreter.name != "java" && isProjectRoot(fsys, applicationDirectory):
+			addCode(applicationDirectory, "application")
+		default:
+			addCode(application.Path, "application")
+		}
+	}
+	for _, entry := range application.ClassPath {
+		// class path wildcards, e.g. lib/*, include all jars of a directory
+		addCode(strings.TrimSuffix(strings.TrimSuffix(entry, "*"), string(filepath.Separator)), "application")
+	}
+
+	switch interpreter.name {
+	case "python":
+		if application.Module != "" && cwd != "" {
+			// modules are searched in the working directory first
+			module := strings.Split(application.Module, ".")[0]
+			addCode(filepath.Join(cwd, module), "application")
+			addCode(filepath.Join(cwd, module+".py"), "application")
+		}
+		for _, directory := range getPythonPackageDirectories(fsys, info.ExecutablePath, args, cwd, environment) {
+			addCode(directory, "packages")
+		}
+		for _, directory := range getEnvironmentPaths("PYTHONPATH") {
+			addCode(directory, "packages")
+		}
+	case "node":
+		// node_modules of the directory of the script and all its parents
+		directory := cwd
+		if application.Path != "" {
+			directory = filepath.Dir(application.Path)
+		}
+		for directory != "" {
+			addCode(filepath.Join(directory, "node_modules"), "packages")
+			parent := filepath.Dir(directory)
+			if parent == directory {
+				break
+			}
+			directory = parent
+		}
+		for _, directory := range getEnvironmentPaths("NODE_PATH") {
+			addCode(directory, "packages")
+		}
+	case "ruby":
+		for _, directory := range getEnvironmentPaths("GEM_HOME", "GEM_PATH", "RUBYLIB") {
+			addCode(directory, "packages")
+		}
+	case "perl":
+		for _, directory := range getEnvironmentPaths("PERL5LIB", "PERLLIB") {
+			addCode(directory, "packages")
+		}
+	case "java":
+		if application.Path == "" && len(application.ClassPath) == 0 {
+			for _, entry := range getEnvironmentPaths("CLASSPATH") {
+				addCode(strings.TrimSuffix(strings.TrimSuffix(entry, "*"), string(filepath.Separator)), "application")
+			}
+		}
+	}
+	return code
+}
+
+// files that mark the root directory of a project
+var projectRootFiles = []string{"package.json", "pyproject.toml", "__main__.py"}
+
+// reports if a directory is the root of a project, e.g. of a Node.js package
+func isProjectRoot(fsys FileSystem, directory string) bool {
+	for _, name := range projectRootFiles {
+		if _, err := fsys.Stat(filepath.Join(directory, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// returns the site-packages directories of a Python interpreter, those of its virtual environment
+// if it runs in one, see https://docs.python.org/3/library/site.html
+func getPythonPackageDirectories(fsys FileSystem, executablePath string, args []string, cwd string, environment []string) []string {
+	// the executable of a virtual environment is a symlink to the interpreter, it is only known by
+	// the command line or the environment of activated virtual environments
+	var virtualEnvironments []string
+	if len(args) > 0 && strings.ContainsRune(args[0], filepath.Separator) {
+		virtualEnvironments = append(virtualEnvironments, filepath.Dir(filepath.Dir(resolveProcessPath(args[0], cwd))))
+	}
+	for _, variable := range environment {
+		if value, found := strings.CutPrefix(variable, "VIRTUAL_ENV="); found {
+			virtualEnvironments = append(virtualEnvironments, value)
+		}
+	}
+
+	prefixes := []string{filepath.Dir(filepath.Dir(executablePath)), "/usr/local"}
+	for _, virtualEnvironment := range virtualEnvironments {
+		file, err := fsys.Open(filepath.Join(virtualEnvironment, "pyvenv.cfg"))
+		if err != nil {
+			continue
+		}
+		config, _ := io.ReadAll(file)
+		file.Close()
+		if pythonSystemSitePackagesPattern.Match(config) {
+			prefixes = append([]string{virtualEnvironment}, prefixes...)
+		} else {
+			prefixes = []string{virtualEnvironment}
+		}
+		break
+	}
+
+	// e.g. lib/python3.11/site-packages and the Debian lib/python3/dist-packages
+	version := ""
+	if match := pythonVersionPattern.FindStringSubmatch(filepath.Base(executablePath)); match != nil {
+		version = match[1]
+	}
+	var directories []string
+	for _, prefix := range prefixes {
+		entries, err := fsys.ReadDir(filepath.Join(prefix, "lib"))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if !strings.HasPrefix(name, "python3") || (version != "" && name != "python"+version && name != "python3") {
+				continue
+			}
+			for _, packages := range []string{"site-packages", "dist-packages"} {
+				directories = append(directories, filepath.Join(prefix, "lib", name, packages))
+			}
+		}
+	}
+	return directories
+}
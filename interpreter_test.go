@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseCommandLine(t *testing.T) {
+	tests := []struct {
+		args []string
+		want InterpretedApplication
+	}{
+		{[]string{"python3"}, InterpretedApplication{}},
+		{[]string{"python3", "app.py", "--port", "80"}, InterpretedApplication{Path: "/srv/app.py"}},
+		{[]string{"python3", "-u", "-W", "ignore", "/opt/app/main.py"}, InterpretedApplication{Path: "/opt/app/main.py"}},
+		{[]string{"python3", "-Wignore", "app.py"}, InterpretedApplication{Path: "/srv/app.py"}},
+		{[]string{"python3", "-X", "dev", "--check-hash-based-pycs", "never", "app.py"}, InterpretedApplication{Path: "/srv/app.py"}},
+		{[]string{"python3", "--check-hash-based-pycs=never", "app.py"}, InterpretedApplication{Path: "/srv/app.py"}},
+		{[]string{"python3", "-m", "http.server", "8080"}, InterpretedApplication{Module: "http.server"}},
+		{[]string{"python3", "-mhttp.server"}, InterpretedApplication{Module: "http.server"}},
+		{[]string{"python3", "-Im", "pip", "install"}, InterpretedApplication{Module: "pip"}},
+		{[]string{"python3", "-BsEm", "app"}, InterpretedApplication{Module: "app"}},
+		{[]string{"python3", "-Bc", "print(1)"}, InterpretedApplication{InlineCode: true}},
+		{[]string{"python3", "-c", "print(1)"}, InterpretedApplication{InlineCode: true}},
+		{[]string{"python3", "-"}, InterpretedApplication{InlineCode: true}},
+		{[]string{"python3", "--", "-app.py"}, InterpretedApplication{Path: "/srv/-app.py"}},
+		// unknown letters are no flag cluster, -Z takes m as value
+		{[]string{"python3", "-Zm", "app.py"}, InterpretedApplication{Path: "/srv/app.py"}},
+		{[]string{"python3", "-m"}, InterpretedApplication{}},
+		{[]string{"node", "--inspect-port=9229", "server.js"}, InterpretedApplication{Path: "/srv/server.js"}},
+		{[]string{"node", "-r", "dotenv/config", "server.js"}, InterpretedApplication{Path: "/srv/server.js"}},
+		{[]string{"node", "-e", "console.log(1)"}, InterpretedApplication{InlineCode: true}},
+		{[]string{"ruby", "-Ilib", "-rjson", "bin/app.rb"}, InterpretedApplication{Path: "/srv/bin/app.rb"}},
+		{[]string{"ruby", "-ne", "print"}, InterpretedApplication{InlineCode: true}},
+		// -K takes the encoding e as value
+		{[]string{"ruby", "-Ke", "app.rb"}, InterpretedApplication{Path: "/srv/app.rb"}},
+		{[]string{"perl", "-lne", "print"}, InterpretedApplication{InlineCode: true}},
+		{[]string{"perl", "-Mstrict", "-w", "script.pl"}, InterpretedApplication{Path: "/srv/script.pl"}},
+		{[]string{"perl", "-pi.bak", "-e", "s/a/b/"}, InterpretedApplication{InlineCode: true}},
+		{[]string{"java", "-Xmx1g", "-jar", "app.jar"}, InterpretedApplication{Path: "/srv/app.jar"}},
+		{[]string{"java", "-cp", "lib/*:classes", "com.example.Main"},
+			InterpretedApplication{Module: "com.example.Main", ClassPath: []string{"/srv/lib/*", "/srv/classes"}}},
+		{[]string{"java", "--module", "app/com.example.Main"}, InterpretedApplication{Module: "app/com.example.Main"}},
+	}
+	for _, test := range tests {
+		interpreter := getInterpreter(test.args[0])
+		if interpreter == nil {
+			t.Fatalf("%s is no interpreter", test.args[0])
+		}
+		test.want.Interpreter = interpreter.name
+		if test.want.ClassPath == nil {
+			test.want.ClassPath = []string{}
+		}
+		if got := interpreter.parseCommandLine(test.args, "/srv"); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %+v, want %+v", test.args, got, test.want)
+		}
+	}
+}
+
+func TestAnalyseInterpretedApplication(t *testing.T) {
+	root := t.TempDir()
+	for _, file := range []string{
+		"/srv/web/package.json", "/srv/web/server.js", "/srv/web/node_modules/express/index.js",
+		"/opt/service/pyproject.toml", "/opt/service/main.py",
+		"/opt/tool/__main__.py", "/opt/tool/cli.py",
+		"/tmp/run.py", "/tmp/unrelated.txt",
+		"/usr/local/bin/backup.rb",
+	} {
+		path := filepath.Join(root, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		args []string
+		want []LibraryInfo
+	}{
+		{[]string{"node", "server.js"}, []LibraryInfo{
+			{Path: "/srv/web", Origin: "application"},
+			{Path: "/srv/web/node_modules", Origin: "packages"},
+		}},
+		{[]string{"python3", "/opt/service/main.py"}, []LibraryInfo{{Path: "/opt/service", Origin: "application"}}},
+		{[]string{"python3", "/opt/tool/cli.py"}, []LibraryInfo{{Path: "/opt/tool", Origin: "application"}}},
+		{[]string{"python3", "/opt/tool"}, []LibraryInfo{{Path: "/opt/tool", Origin: "application"}}},
+		// scripts outside of a project count on their own
+		{[]string{"python3", "/tmp/run.py"}, []LibraryInfo{{Path: "/tmp/run.py", Origin: "application"}}},
+		{[]string{"ruby", "/usr/local/bin/backup.rb"}, []LibraryInfo{{Path: "/usr/local/bin/backup.rb", Origin: "application"}}},
+	}
+	for _, test := range tests {
+		info := ProcessInfo{Name: test.args[0], ExecutablePath: "/usr/bin/" + test.args[0]}
+		interpreter := getInterpreter(info.ExecutablePath)
+		application := interpreter.parseCommandLine(test.args, "/srv/web")
+		code := analyseInterpretedApplication(rootFileSystem{root: root}, &info, *interpreter, application, test.args, "/srv/web", nil)
+		if !reflect.DeepEqual(code, test.want) {
+			t.Errorf("%v: got %+v, want %+v", test.args, code, test.want)
+		}
+		// the command line names the application, it doesn't prove its language like structural evidence
+		if len(info.WeightedLanguageEvidence) != 1 || info.WeightedLanguageEvidence[0].Source != evidenceCommandLine ||
+			info.LanguageConfidence >= evidenceWeights[evidenceStructural] {
+			t.Errorf("%v: got evidence %+v with confidence %.2f", test.args, info.WeightedLanguageEvidence, info.LanguageConfidence)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// JavaScriptBundle describes a JavaScript or TypeScript application compiled into a single
+// executable together with its runtime
+type JavaScriptBundle struct {
+	Packager           string `json:"packager"`              // Node SEA, pkg, Deno or Bun
+	RuntimeVersion     string `json:"runtime_version"`       // version of the embedded Node, Deno or Bun runtime, empty if unknown
+	PayloadSizeInBytes int64  `json:"payload_size_in_bytes"` // size of the embedded scripts and assets
+}
+
+// single executable applications of Node.js embed their blob as ELF note, Mach-O section or PE resource
+// https://nodejs.org/api/single-executable-applications.html
+const nodeSeaBlobName = "NODE_SEA_BLOB"
+
+// deno compile appends the eszip archive with a trailer of magic, eszip and metadata offset, newer
+// versions store it in a d3n0l4nd section or resource instead
+var denoTrailerMagic = []byte("d3n0l4nd")
+
+const denoTrailerSize = 24
+
+// bun build --compile appends its module graph followed by this trailer, or stores it in a bun section
+var bunTrailer = []byte("\n---- Bun! ----\n")
+
+// the trailers are at the very end, only followed by a code signature
+const javaScriptTrailerSearchSize = 64 * 1024
+
+// vercel/pkg patches the payload position and size into the bootstrap code of its Node.js binaries
+var pkgPayloadSizePattern = regexp.MustCompile(`PAYLOAD_SIZE\D{0,32}?(\d+)`)
+
+var nodeVersionPattern = regexp.MustCompile(`nodejs\.org/download/release/(v\d+\.\d+\.\d+)`)
+var denoVersionPattern = regexp.MustCompile(`Deno/(\d+\.\d+\.\d+)`)
+var bunVersionPattern = regexp.MustCompile(`Bun v(\d+\.\d+\.\d+)`)
+
+const rtRcdata = 10 // PE resource type of raw data
+
+// detects JavaScript/TypeScript applications compiled into an executable: Node.js single executable
+// applications, vercel/pkg, deno compile and bun build --compile, returns nil for other executables
+func getJavaScriptBundle(file File, fileType string) *JavaScriptBundle {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil
+	}
+	fileSize := fileInfo.Size()
+	tailStart := max(0, fileSize-javaScriptTrailerSearchSize)
+	tail := make([]byte, fileSize-tailStart)
+	if _, err := file.ReadAt(tail, tailStart); err != nil {
+		return nil
+	}
+
+	// size of the embedded sections by their name
+	var seaBlobSize, denoSectionSize, bunSectionSize int64 = -1, -1, -1
+	var overlaySize int64
+	switch fileType {
+	case "ELF":
+		f, err := elf.NewFile(file)
+		if err != nil {
+			return nil
+		}
+		seaBlobSize = getElfNoteSize(f, nodeSeaBlobName)
+		denoSectionSize = getElfSectionSize(f, "d3n0l4nd")
+		bunSectionSize = getElfSectionSize(f, ".bun")
+		overlaySize = fileSize - getElfImageEnd(file, f)
+	case "PE":
+		f, err := pe.NewFile(file)
+		if err != nil {
+			return nil
+		}
+		seaBlobSize = getPEResourceSize(f, rtRcdata, nodeSeaBlobName)
+		denoSectionSize = getPEResourceSize(f, rtRcdata, "D3N0L4ND")
+		if section := f.Section(".bun"); section != nil {
+			bunSectionSize = int64(section.Size)
+		}
+		var imageEnd int64
+		for _, section := range f.Sections {
+			imageEnd = max(imageEnd, int64(section.Offset)+int64(section.Size))
+		}
+		overlaySize = fileSize - imageEnd
+	case "Mach-O":
+		f, err := macho.NewFile(file)
+		if err != nil {
+			return nil
+		}
+		getSectionSize := func(name string) int64 {
+			if section := f.Section(name); section != nil {
+				return int64(section.Size)
+			}
+			return -1
+		}
+		seaBlobSize = getSectionSize("__NODE_SEA_BLOB")
+		denoSectionSize = getSectionSize("__d3n0l4nd")
+		bunSectionSize = getSectionSize("__bun")
+	}
+	overlaySize = max(0, overlaySize)
+
+	var bundle *JavaScriptBundle
+	var versionPattern *regexp.Regexp
+	switch {
+	case seaBlobSize >= 0:
+		bundle = &JavaScriptBundle{Packager: "Node SEA", PayloadSizeInBytes: seaBlobSize}
+		versionPattern = nodeVersionPattern
+	case denoSectionSize >= 0:
+		bundle = &JavaScriptBundle{Packager: "Deno", PayloadSizeInBytes: denoSectionSize}
+		versionPattern = denoVersionPattern
+	case len(tail) >= denoTrailerSize && bytes.HasPrefix(tail[len(tail)-denoTrailerSize:], denoTrailerMagic):
+		// the payload starts at the eszip offset and ends with the trailer
+		eszipOffset := int64(binary.BigEndian.Uint64(tail[len(tail)-denoTrailerSize+8:]))
+		payloadSize := fileSize - denoTrailerSize - eszipOffset
+		if eszipOffset > fileSize || payloadSize < 0 {
+			payloadSize = overlaySize
+		}
+		bundle = &JavaScriptBundle{Packager: "Deno", PayloadSizeInBytes: payloadSize}
+		versionPattern = denoVersionPattern
+	case bunSectionSize >= 0:
+		bundle = &JavaScriptBundle{Packager: "Bun", PayloadSizeInBytes: bunSectionSize}
+		versionPattern = bunVersionPattern
+	case bytes.Contains(tail, bunTrailer):
+		bundle = &JavaScriptBundle{Packager: "Bun", PayloadSizeInBytes: overlaySize}
+		versionPattern = bunVersionPattern
+	}
+
+	readOnlyData := getReadOnlyData(file, fileType)
+	if bundle == nil && bytes.Contains(readOnlyData, []byte("PAYLOAD_POSITION")) && overlaySize > 0 {
+		// the placeholders are only replaced in binaries with a payload, which is appended
+		bundle = &JavaScriptBundle{Packager: "pkg", PayloadSizeInBytes: overlaySize}
+		if match := pkgPayloadSizePattern.FindSubmatch(readOnlyData); match != nil {
+			if size, err := strconv.ParseInt(string(match[1]), 10, 64); err == nil && size > 0 && size <= fileSize {
+				bundle.PayloadSizeInBytes = size
+			}
+		}
+		versionPattern = nodeVersionPattern
+	}
+	if bundle == nil {
+		return nil
+	}
+
+	if match := versionPattern.FindSubmatch(readOnlyData); match != nil {
+		bundle.RuntimeVersion = string(match[1])
+	}
+	return bundle
+}
+
+// records a compiled JavaScript/TypeScript application with the evidence it gives for its language
+func addJavaScriptBundle(info BinaryLanguageInfo, bundle *JavaScriptBundle) BinaryLanguageInfo {
+	if bundle == nil {
+		return info
+	}
+	info.JavaScriptBundle = bundle
+	// the scripts are transpiled to JavaScript, TypeScript sources can't be told apart anymore
+	info.addEvidence(evidenceStructural, "JavaScript bundle: "+strings.TrimSpace(bundle.Packager+" "+bundle.RuntimeVersion), "JavaScript/TypeScript")
+	return info
+}
+
+func getElfSectionSize(f *elf.File, name string) int64 {
+	if section := f.Section(name); section != nil {
+		return int64(section.Size)
+	}
+	return -1
+}
+
+// returns the size of the description of the first ELF note with a name, e.g. NODE_SEA_BLOB
+// injected by postject, which adds a PT_NOTE segment without a section
+func getElfNoteSize(f *elf.File, name string) int64 {
+	var noteBlocks [][]byte
+	for _, prog := range f.Progs {
+		if prog.Type == elf.PT_NOTE {
+			notes := make([]byte, prog.Filesz)
+			if _, err := prog.ReadAt(notes, 0); err == nil {
+				noteBlocks = append(noteBlocks, notes)
+			}
+		}
+	}
+	for _, section := range f.Sections {
+		if section.Type == elf.SHT_NOTE {
+			if notes, err := section.Data(); err == nil {
+				noteBlocks = append(noteBlocks, notes)
+			}
+		}
+	}
+
+	for _, notes := range noteBlocks {
+		// name size, description size, type, padded name and padded description
+		for len(notes) >= 12 {
+			nameSize := int64(f.ByteOrder.Uint32(notes[0:4]))
+			descriptionSize := int64(f.ByteOrder.Uint32(notes[4:8]))
+			nameEnd := 12 + (nameSize+3)&^3
+			descriptionEnd := nameEnd + (descriptionSize+3)&^3
+			if descriptionEnd > int64(len(notes)) {
+				break
+			}
+			if string(bytes.TrimRight(notes[12:12+nameSize], "\x00")) == name {
+				return descriptionSize
+			}
+			notes = notes[descriptionEnd:]
+		}
+	}
+	return -1
+}
+
+// returns the end of the ELF image in the file, data behind it was appended to the executable
+func getElfImageEnd(file File, f *elf.File) int64 {
+	var end int64
+	for _, prog := range f.Progs {
+		end = max(end, int64(prog.Off+prog.Filesz))
+	}
+	for _, section := range f.Sections {
+		if section.Type != elf.SHT_NOBITS {
+			end = max(end, int64(section.Offset+section.FileSize))
+		}
+	}
+
+	// the section header table is usually the last part of the image, but debug/elf doesn't expose
+	// its offset: e_shoff, e_shentsize and e_shnum of the ELF header
+	header := make([]byte, 64)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return end
+	}
+	var headersOffset, headerSize, headerCount uint64
+	if f.Class == elf.ELFCLASS64 {
+		headersOffset = f.ByteOrder.Uint64(header[0x28:])
+		headerSize = uint64(f.ByteOrder.Uint16(header[0x3a:]))
+		headerCount = uint64(f.ByteOrder.Uint16(header[0x3c:]))
+	} else {
+		headersOffset = uint64(f.ByteOrder.Uint32(header[0x20:]))
+		headerSize = uint64(f.ByteOrder.Uint16(header[0x2e:]))
+		headerCount = uint64(f.ByteOrder.Uint16(header[0x30:]))
+	}
+	return max(end, int64(headersOffset+headerSize*headerCount))
+}
+
+// returns the size of a named PE resource of a type, e.g. the NODE_SEA_BLOB RCDATA resource
+func getPEResourceSize(f *pe.File, resourceType uint32, name string) int64 {
+	section := f.Section(".rsrc")
+	if section == nil {
+		return -1
+	}
+	data, err := section.Data()
+	if err != nil {
+		return -1
+	}
+
+	// resource directory: characteristics, timestamp, version, number of named and id entries,
+	// followed by 8 byte entries of name or id and offset of the subdirectory or data entry
+	readEntries := func(offset uint32) [][2]uint32 {
+		if int(offset)+16 > len(data) {
+			return nil
+		}
+		count := int(binary.LittleEndian.Uint16(data[offset+12:])) + int(binary.LittleEndian.Uint16(data[offset+14:]))
+		var entries [][2]uint32
+		for i := 0; i < count; i++ {
+			entryOffset := int(offset) + 16 + i*8
+			if entryOffset+8 > len(data) {
+				break
+			}
+			entries = append(entries, [2]uint32{
+				binary.LittleEndian.Uint32(data[entryOffset:]),
+				binary.LittleEndian.Uint32(data[entryOffset+4:]),
+			})
+		}
+		return entries
+	}
+	readName := func(offset uint32) string {
+		if int(offset)+2 > len(data) {
+			return ""
+		}
+		length := int(binary.LittleEndian.Uint16(data[offset:]))
+		if int(offset)+2+length*2 > len(data) {
+			return ""
+		}
+		name := make([]uint16, length)
+		for i := range name {
+			name[i] = binary.LittleEndian.Uint16(data[int(offset)+2+i*2:])
+		}
+		return string(utf16.Decode(name))
+	}
+	const highBit = 0x80000000
+
+	// type, name and language level
+	for _, typeEntry := range readEntries(0) {
+		if typeEntry[0] != resourceType || typeEntry[1]&highBit == 0 {
+			continue
+		}
+		for _, nameEntry := range readEntries(typeEntry[1] &^ highBit) {
+			if nameEntry[0]&highBit == 0 || !strings.EqualFold(readName(nameEntry[0]&^highBit), name) || nameEntry[1]&highBit == 0 {
+				continue
+			}
+			for _, languageEntry := range readEntries(nameEntry[1] &^ highBit) {
+				// data entry: RVA, size, code page and reserved
+				dataEntry := languageEntry[1]
+				if dataEntry&highBit != 0 || int(dataEntry)+8 > len(data) {
+					continue
+				}
+				return int64(binary.LittleEndian.Uint32(data[dataEntry+4:]))
+			}
+		}
+	}
+	return -1
+}
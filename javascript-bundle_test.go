@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestAnalyseJavaScriptBundle(t *testing.T) {
+	tests := []struct {
+		file        string
+		packager    string
+		payloadSize int64
+	}{
+		// 54 bytes blob of node --experimental-sea-config
+		{"hello-node-sea", "Node SEA", 54},
+		{"hello-deno", "Deno", 38},
+		{"hello-bun", "Bun", 38},
+	}
+	for _, test := range tests {
+		info := ProcessInfo{ExecutablePath: "testdata/language-corpus/" + test.file}
+		analyseExecutableSize(hostFileSystem{}, &info)
+		analyseExecutableLanguage(hostFileSystem{}, &info)
+
+		if info.JavaScriptBundle == nil || info.JavaScriptBundle.Packager != test.packager {
+			t.Errorf("%s: got bundle %+v, want %s", test.file, info.JavaScriptBundle, test.packager)
+			continue
+		}
+		if info.ExecutablePayloadSizeInBytes != test.payloadSize {
+			t.Errorf("%s: got payload of %d bytes, want %d", test.file, info.ExecutablePayloadSizeInBytes, test.payloadSize)
+		}
+		// the payload is not machine code, the size of the executable code stays as measured
+		if info.ExecutableSizeMethod != sizeMethodElfSegments {
+			t.Errorf("%s: got size method %q", test.file, info.ExecutableSizeMethod)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// corpus of binaries with known source language, see testdata/language-corpus/build.sh
+const languageCorpus = "testdata/language-corpus"
+
+// labels.json lists the binaries of the corpus with the language they were written in
+type languageCorpusLabels struct {
+	Fixtures []struct {
+		File     string `json:"file"` // relative to the corpus directory
+		Language string `json:"language"`
+		// built by a script that writes the markers the detectors look for, e.g. without PyInstaller,
+		// so it tests the detector but says nothing about its accuracy on real binaries
+		Synthetic bool `json:"synthetic"`
+	} `json:"fixtures"`
+}
+
+func TestLanguageCorpus(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join(languageCorpus, "labels.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels languageCorpusLabels
+	if err := json.Unmarshal(data, &labels); err != nil {
+		t.Fatal(err)
+	}
+	if len(labels.Fixtures) == 0 {
+		t.Fatal("corpus has no fixtures")
+	}
+
+	// accuracy and confidence are only measured with binaries of the real toolchains
+	fixtures, correct := 0, 0
+	var correctConfidence float64
+	for _, fixture := range labels.Fixtures {
+		if !fixture.Synthetic {
+			fixtures++
+		}
+		t.Run(fixture.File, func(t *testing.T) {
+			info, err := DetectSourceLanguageFromBinary(filepath.Join(languageCorpus, fixture.File))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.MostLikelyLanguage != fixture.Language {
+				t.Fatalf("detected %s with confidence %.2f, want %s, candidates: %v, evidence: %v",
+					info.MostLikelyLanguage, info.Confidence, fixture.Language, info.PossibleLanguages, info.Evidence)
+			}
+			if !fixture.Synthetic {
+				correct++
+				correctConfidence += info.Confidence
+			}
+			t.Logf("%s with confidence %.2f, candidates: %v", info.MostLikelyLanguage, info.Confidence, info.PossibleLanguages)
+		})
+	}
+	t.Logf("accuracy: %d/%d binaries of real toolchains, %d synthetic binaries", correct, fixtures, len(labels.Fixtures)-fixtures)
+	if correct > 0 {
+		t.Logf("mean confidence of correct detections: %.2f", correctConfidence/float64(correct))
+	}
+}
@@ -8,26 +8,125 @@ import (
 	"encoding/binary"
 	"fmt"
 	"io"
-	"os"
+	"math"
 	"regexp"
 	"runtime"
 	"strings"
+
+	. "github.com/ahmetb/go-linq" // LINQ for Go to manage data structure like its 2025
 )
 
 // BinaryLanguageInfo holds information about the detected source language
 type BinaryLanguageInfo struct {
-	MostLikelyLanguage string   // Primary language guess
-	Confidence         float64  // Confidence score (0-1)
-	PossibleLanguages  []string // Other possible languages
-	Evidence           []string // Supporting evidence
-	FileType           string   // Binary format type
-	Platform           string   // Target platform
+	MostLikelyLanguage string             // Primary language guess
+	Confidence         float64            // Confidence score (0-1)
+	PossibleLanguages  []string           // All languages with evidence, most likely first
+	Evidence           []string           // Supporting evidence, including parsing failures
+	WeightedEvidence   []LanguageEvidence // Evidence pointing to languages with its source and weight
+	FileType           string             // Binary format type
+	Platform           string             // Target platform
+	Hardening          *BinaryHardening   // Exploit mitigations, nil for formats other than ELF and PE
+	GoBuildInfo        *GoBuildInfo       // Toolchain, modules and build settings, nil for binaries of other languages
+	RustBuildInfo      *RustBuildInfo     // Compiler and crates, nil for binaries of other languages
+	PythonBundle       *PythonBundle      // Frozen Python application, e.g. PyInstaller, nil for other binaries
+	JavaScriptBundle   *JavaScriptBundle  // Compiled JavaScript/TypeScript application, e.g. Node SEA, nil for other binaries
+	DotNetAssembly     *DotNetAssembly    // CLI metadata, nil for binaries other than .NET assemblies
+}
+
+// sources of language evidence, from the most to the least reliable
+const (
+	evidenceStructural  = "structural"       // sections or metadata only the toolchain of a language emits, e.g. .go.buildinfo
+	evidenceSymbol      = "symbol"           // symbols of the language runtime, e.g. mangled Rust core symbols
+	evidenceCommandLine = "command line"     // application named on the command line of a runtime or interpreter, e.g. python3 app.py
+	evidenceLinkage     = "linkage"          // linked runtime library of a language, e.g. libstdc++
+	evidenceString      = "string heuristic" // pattern in the first 64KB of the binary
+	evidenceElimination = "elimination"      // no evidence for the other candidates, e.g. C# for .NET assemblies
+	evidenceBaseline    = "baseline"         // C runtime that nearly all native binaries link
+)
+
+// weight of a single piece of evidence of each source, the weights are set by hand to rank the sources
+// by their reliability, the corpus of labelled binaries is too small to fit them
+var evidenceWeights = map[string]float64{
+	evidenceStructural:  0.95,
+	evidenceSymbol:      0.85,
+	evidenceCommandLine: 0.7,
+	evidenceLinkage:     0.6,
+	evidenceString:      0.3,
+	evidenceElimination: 0.3,
+	evidenceBaseline:    0.2,
+}
+
+// LanguageEvidence is a piece of evidence for the source language of a binary, evidence that can't
+// tell languages apart, e.g. .NET metadata, splits its weight between them
+type LanguageEvidence struct {
+	Languages   []string `json:"languages"`
+	Source      string   `json:"source"`
+	Weight      float64  `json:"weight"`
+	Description string   `json:"description"`
+}
+
+// records evidence for one or more languages, the weight is given by the source
+func (info *BinaryLanguageInfo) addEvidence(source string, description string, languages ...string) {
+	info.WeightedEvidence = append(info.WeightedEvidence, LanguageEvidence{
+		Languages:   languages,
+		Source:      source,
+		Weight:      evidenceWeights[source],
+		Description: description,
+	})
+	info.Evidence = append(info.Evidence, description)
+}
+
+// BinaryHardening holds the exploit mitigations a binary was built with, which make memory
+// corruption bugs much harder to exploit
+type BinaryHardening struct {
+	Relro         string `json:"relro,omitempty"` // ELF only: none, partial or full (GNU_RELRO + BIND_NOW)
+	Pie           bool   `json:"pie"`             // ELF: position independent executable, PE: ASLR (DYNAMIC_BASE)
+	Nx            bool   `json:"nx"`              // ELF: non-executable GNU_STACK, PE: DEP (NX_COMPAT)
+	StackCanary   bool   `json:"stack_canary"`    // ELF only: calls __stack_chk_fail
+	Fortify       bool   `json:"fortify"`         // ELF only: calls _FORTIFY_SOURCE checked functions like __memcpy_chk
+	Ibt           bool   `json:"ibt"`             // ELF only: CET indirect branch tracking
+	Shstk         bool   `json:"shstk"`           // ELF only: CET shadow stack
+	Cfg           bool   `json:"cfg"`             // PE only: control flow guard
+	HighEntropyVa bool   `json:"high_entropy_va"` // PE only: 64-bit ASLR
+}
+
+// lists the enabled and missing mitigations, e.g. "full RELRO, PIE, NX, canary, no FORTIFY, no IBT, no SHSTK"
+func (h BinaryHardening) String() string {
+	var checks []string
+	check := func(name string, enabled bool) {
+		if enabled {
+			checks = append(checks, name)
+		} else {
+			checks = append(checks, "no "+name)
+		}
+	}
+	if h.Relro == "" {
+		// PE
+		check("ASLR", h.Pie)
+		check("DEP", h.Nx)
+		check("CFG", h.Cfg)
+		check("HIGH_ENTROPY_VA", h.HighEntropyVa)
+	} else {
+		checks = append(checks, h.Relro+" RELRO")
+		check("PIE", h.Pie)
+		check("NX", h.Nx)
+		check("canary", h.StackCanary)
+		check("FORTIFY", h.Fortify)
+		check("IBT", h.Ibt)
+		check("SHSTK", h.Shstk)
+	}
+	return strings.Join(checks, ", ")
 }
 
 // analyzes a binary to determine the source language
 func DetectSourceLanguageFromBinary(filePath string) (BinaryLanguageInfo, error) {
+	return DetectSourceLanguageFromFileSystem(hostFileSystem{}, filePath)
+}
+
+// analyzes a binary of a file system to determine the source language, e.g. of a root directory
+func DetectSourceLanguageFromFileSystem(fsys FileSystem, filePath string) (BinaryLanguageInfo, error) {
 	info := BinaryLanguageInfo{}
-	file, err := os.Open(filePath)
+	file, err := fsys.Open(filePath)
 	if err != nil {
 		return info, err
 	}
@@ -47,6 +146,17 @@ func DetectSourceLanguageFromBinary(filePath string) (BinaryLanguageInfo, error)
 		return info, err
 	}
 
+	// Go binaries embed their build info in all formats
+	if goBuildInfo, err := getGoBuildInfo(file); err == nil {
+		info.GoBuildInfo = goBuildInfo
+		info.addEvidence(evidenceStructural, "Go build info: "+goBuildInfo.GoVersion, "Go")
+	}
+
+	// frozen Python and compiled JavaScript applications are reported as the language of their
+	// bootloader or runtime otherwise
+	info = addPythonBundle(info, getPythonBundle(file, fileType))
+	info = addJavaScriptBundle(info, getJavaScriptBundle(file, fileType))
+
 	// Language detection based on file type
 	switch fileType {
 	case "PE":
@@ -72,7 +182,7 @@ func DetectSourceLanguageFromBinary(filePath string) (BinaryLanguageInfo, error)
 	return info, nil
 }
 
-func detectFileType(file *os.File) (string, string, error) {
+func detectFileType(file File) (string, string, error) {
 	// read first 8 bytes to detect file type
 	magic := make([]byte, 8)
 	_, err := file.Read(magic)
@@ -83,9 +193,11 @@ func detectFileType(file *os.File) (string, string, error) {
 	// Check for Mach-O universal binary first (fat binary)
 	if len(magic) >= 8 {
 		fatMagic := binary.BigEndian.Uint32(magic[0:4])
-		if fatMagic == 0xcafebabe || fatMagic == 0xcaaebabe {
+		narch := binary.BigEndian.Uint32(magic[4:8])
+		// Java class files share the magic, their class file version is always bigger than the
+		// number of architectures of a universal binary
+		if (fatMagic == 0xcafebabe || fatMagic == 0xcaaebabe) && narch < 20 {
 			// This is a Mach-O universal binary (fat binary)
-			narch := binary.BigEndian.Uint32(magic[4:8])
 			return "Mach-O Universal", fmt.Sprintf("macOS (%d architectures)", narch), nil
 		}
 	}
@@ -118,52 +230,62 @@ func detectFileType(file *os.File) (string, string, error) {
 	}
 }
 
-func analyzePEFile(file *os.File, info BinaryLanguageInfo) BinaryLanguageInfo {
-	peFile, err := pe.NewFile(file)
+func analyzePEFile(file File, info BinaryLanguageInfo) BinaryLanguageInfo {
+	peFile, err := newPEFile(file)
 	if err != nil {
 		info.Evidence = append(info.Evidence, "PE parsing failed: "+err.Error())
 		return info
 	}
 	defer peFile.Close()
 
-	// Check for .NET assemblies which indicate C#/VB/F#
+	// Check for .NET assemblies, their referenced runtime libraries tell C#/VB/F# apart
 	if hasDotNetMetadata(peFile) {
-		info.PossibleLanguages = append(info.PossibleLanguages, "C#", "VB.NET", "F#")
-		info.Evidence = append(info.Evidence, "Found .NET metadata")
+		assembly, err := getDotNetAssembly(peFile)
+		if err != nil {
+			info.Evidence = append(info.Evidence, ".NET metadata parsing failed: "+err.Error())
+			info.addEvidence(evidenceStructural, "Found .NET metadata", "C#", "VB.NET", "F#")
+		} else {
+			info.DotNetAssembly = assembly
+			language, source, reason := getDotNetLanguage(assembly)
+			if source != evidenceStructural {
+				// the metadata itself only tells that it is one of the .NET languages
+				info.addEvidence(evidenceStructural, "Found .NET metadata", "C#", "VB.NET", "F#")
+			}
+			info.addEvidence(source, "Found .NET metadata, "+reason, language)
+		}
 	}
 
-	// Check for Go-specific characteristics
-	if isGoBinary(peFile) {
-		info.PossibleLanguages = append(info.PossibleLanguages, "Go")
-		info.Evidence = append(info.Evidence, "Found Go runtime indicators")
+	// Check for Go-specific characteristics if the build info is missing
+	if info.GoBuildInfo == nil && isGoBinary(peFile) {
+		info.addEvidence(evidenceStructural, "Found Go runtime indicators", "Go")
 	}
 
 	// Check for Rust characteristics
 	if isRustBinary(peFile) {
-		info.PossibleLanguages = append(info.PossibleLanguages, "Rust")
-		info.Evidence = append(info.Evidence, "Found Rust panic strings")
+		info.addEvidence(evidenceSymbol, "Found Rust panic strings", "Rust")
 	}
+	info = addRustBuildInfo(info, getPERustBuildInfo(peFile))
+
+	hardening := getPEHardening(peFile)
+	info.Hardening = &hardening
 
 	// Check imported libraries
 	imports := getPEImports(peFile)
 	for _, i := range imports {
 		switch {
 		case strings.Contains(i, "go_") || i == "runtime.dll":
-			info.PossibleLanguages = append(info.PossibleLanguages, "Go")
-			info.Evidence = append(info.Evidence, "Go runtime: "+i)
-		case strings.Contains(i, "Qt"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "C++")
-			info.Evidence = append(info.Evidence, "Qt framework: "+i)
+			info.addEvidence(evidenceLinkage, "Go runtime: "+i, "Go")
+		case strings.HasPrefix(i, "qt"):
+			info.addEvidence(evidenceLinkage, "Qt framework: "+i, "C++")
 		case strings.Contains(i, "msvcr") || strings.Contains(i, "vcruntime"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "C", "C++")
-			info.Evidence = append(info.Evidence, "MSVC runtime: "+i)
+			info.addEvidence(evidenceLinkage, "MSVC runtime: "+i, "C", "C++")
 		}
 	}
 
 	return info
 }
 
-func analyzeElfFile(file *os.File, info BinaryLanguageInfo) BinaryLanguageInfo {
+func analyzeElfFile(file File, info BinaryLanguageInfo) BinaryLanguageInfo {
 	elfFile, err := elf.NewFile(file)
 	if err != nil {
 		info.Evidence = append(info.Evidence, "ELF parsing failed: "+err.Error())
@@ -171,41 +293,36 @@ func analyzeElfFile(file *os.File, info BinaryLanguageInfo) BinaryLanguageInfo {
 	}
 	defer elfFile.Close()
 
-	// Check for Go-specific sections
-	if hasGoBuildInfo(elfFile) {
-		info.PossibleLanguages = append(info.PossibleLanguages, "Go")
-		info.Evidence = append(info.Evidence, "Found Go build info")
-	}
-
 	// Check for Rust characteristics
 	if hasRustSymbols(elfFile) {
-		info.PossibleLanguages = append(info.PossibleLanguages, "Rust")
-		info.Evidence = append(info.Evidence, "Found Rust symbols")
+		info.addEvidence(evidenceSymbol, "Found Rust symbols", "Rust")
 	}
+	info = addRustBuildInfo(info, getElfRustBuildInfo(elfFile))
+
+	hardening := getElfHardening(elfFile)
+	info.Hardening = &hardening
 
 	// Check dynamic libraries
 	libs, _ := elfFile.ImportedLibraries()
 	for _, lib := range libs {
 		switch {
 		case strings.Contains(lib, "libgo"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "Go")
-			info.Evidence = append(info.Evidence, "Go library: "+lib)
+			info.addEvidence(evidenceLinkage, "Go library: "+lib, "Go")
 		case strings.Contains(lib, "libstdc++"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "C++")
-			info.Evidence = append(info.Evidence, "C++ stdlib: "+lib)
+			info.addEvidence(evidenceLinkage, "C++ stdlib: "+lib, "C++")
 		case strings.Contains(lib, "libgfortran"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "Fortran")
-			info.Evidence = append(info.Evidence, "Fortran library: "+lib)
+			info.addEvidence(evidenceLinkage, "Fortran library: "+lib, "Fortran")
 		case strings.Contains(lib, "libpython"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "Python")
-			info.Evidence = append(info.Evidence, "Python library: "+lib)
+			info.addEvidence(evidenceLinkage, "Python library: "+lib, "Python")
+		case strings.HasPrefix(lib, "libc.so") || strings.HasPrefix(lib, "libc.musl"):
+			info.addEvidence(evidenceBaseline, "C runtime: "+lib, "C")
 		}
 	}
 
 	return info
 }
 
-func analyzeMachOFile(file *os.File, info BinaryLanguageInfo) BinaryLanguageInfo {
+func analyzeMachOFile(file File, info BinaryLanguageInfo) BinaryLanguageInfo {
 	// First check if this is a universal binary
 	magic := make([]byte, 8)
 	_, err := file.Read(magic)
@@ -232,16 +349,7 @@ func analyzeMachOFile(file *os.File, info BinaryLanguageInfo) BinaryLanguageInfo
 				info.Evidence = append(info.Evidence, "Failed to analyse fat Mach-O file: "+err.Error())
 				return info
 			}
-			for _, arch := range fatFile.Arches {
-				if strings.ToLower(arch.Cpu.String())[3:] == runtime.GOARCH {
-					// analyse our architecture
-					machoFile = arch.File
-				}
-			}
-			if machoFile == nil && len(fatFile.Arches) > 0 {
-				// if no matching arch found, take first
-				machoFile = fatFile.Arches[0].File
-			}
+			machoFile = selectMachOArchitecture(fatFile)
 		} else {
 			// Handle regular Mach-O binaries
 			machoFile, err = macho.NewFile(file)
@@ -257,20 +365,19 @@ func analyzeMachOFile(file *os.File, info BinaryLanguageInfo) BinaryLanguageInfo
 
 	// Check for Swift metadata
 	if hasSwiftSections(machoFile) {
-		info.PossibleLanguages = append(info.PossibleLanguages, "Swift")
-		info.Evidence = append(info.Evidence, "Found Swift metadata")
+		info.addEvidence(evidenceStructural, "Found Swift metadata", "Swift")
 	}
 
-	// Check for Go build info
-	if hasGoBuildID(machoFile) {
-		info.PossibleLanguages = append(info.PossibleLanguages, "Go")
-		info.Evidence = append(info.Evidence, "Found Go build ID")
+	// Check for Go sections if the build info is missing
+	if info.GoBuildInfo == nil && hasGoBuildID(machoFile) {
+		info.addEvidence(evidenceStructural, "Found Go build ID", "Go")
 	}
 
+	info = addRustBuildInfo(info, getMachORustBuildInfo(machoFile))
+
 	// Check for Objective-C segments
 	if hasObjCSections(machoFile) {
-		info.PossibleLanguages = append(info.PossibleLanguages, "Objective-C")
-		info.Evidence = append(info.Evidence, "Found Objective-C segments")
+		info.addEvidence(evidenceStructural, "Found Objective-C segments", "Objective-C")
 	}
 
 	// Check imported libraries
@@ -278,21 +385,20 @@ func analyzeMachOFile(file *os.File, info BinaryLanguageInfo) BinaryLanguageInfo
 	for _, lib := range libs {
 		switch {
 		case strings.Contains(lib, "libswift"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "Swift")
-			info.Evidence = append(info.Evidence, "Swift library: "+lib)
+			info.addEvidence(evidenceLinkage, "Swift library: "+lib, "Swift")
 		case strings.Contains(lib, "libobjc"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "Objective-C")
-			info.Evidence = append(info.Evidence, "Objective-C runtime: "+lib)
+			info.addEvidence(evidenceLinkage, "Objective-C runtime: "+lib, "Objective-C")
 		case strings.Contains(lib, "libc++"):
-			info.PossibleLanguages = append(info.PossibleLanguages, "C++")
-			info.Evidence = append(info.Evidence, "C++ runtime: "+lib)
+			info.addEvidence(evidenceLinkage, "C++ runtime: "+lib, "C++")
+		case strings.Contains(lib, "libSystem"):
+			info.addEvidence(evidenceBaseline, "C runtime: "+lib, "C")
 		}
 	}
 
 	return info
 }
 
-func checkForLanguageSpecificStrings(file *os.File, info BinaryLanguageInfo) BinaryLanguageInfo {
+func checkForLanguageSpecificStrings(file File, info BinaryLanguageInfo) BinaryLanguageInfo {
 	// Reset file reader
 	_, err := file.Seek(0, 0)
 	if err != nil {
@@ -306,52 +412,95 @@ func checkForLanguageSpecificStrings(file *os.File, info BinaryLanguageInfo) Bin
 		return info
 	}
 
-	// Patterns for different languages
-	patterns := map[string]*regexp.Regexp{
-		"Go":     regexp.MustCompile(`runtime\.|go(itab|type|func|string|interface)`),
-		"Rust":   regexp.MustCompile(`rust_panic|rust_begin_unwind|core::`),
-		"C++":    regexp.MustCompile(`\.cxx_|std::|__cxa_|typeinfo for`),
-		"Python": regexp.MustCompile(`PyImport_|PyEval_|Python\d\.\d`),
-		"Java":   regexp.MustCompile(`java/|javax/`),
-		"Node":   regexp.MustCompile(`node\.js|require\(`),
+	// Patterns for different languages, in a fixed order so the evidence is reported deterministically
+	patterns := []struct {
+		language string
+		pattern  *regexp.Regexp
+	}{
+		{"Go", regexp.MustCompile(`runtime\.|go(itab|type|func|string|interface)`)},
+		{"Rust", regexp.MustCompile(`rust_panic|rust_begin_unwind|core::`)},
+		// not __cxa_finalize or __cxa_atexit, which every glibc binary imports
+		{"C++", regexp.MustCompile(`\.cxx_|std::|__cxa_(throw|begin_catch|allocate_exception)|typeinfo for`)},
+		{"Python", regexp.MustCompile(`PyImport_|PyEval_|Python\d\.\d`)},
+		{"Java", regexp.MustCompile(`java/|javax/`)},
 	}
 
-	for lang, pattern := range patterns {
-		if pattern.Match(data) {
-			info.PossibleLanguages = append(info.PossibleLanguages, lang)
-			info.Evidence = append(info.Evidence, fmt.Sprintf("Found %s patterns in binary", lang))
+	for _, p := range patterns {
+		if p.pattern.Match(data) {
+			info.addEvidence(evidenceString, fmt.Sprintf("Found %s patterns in binary", p.language), p.language)
 		}
 	}
 
 	return info
 }
 
+// scores each language with the sum of the weights of its evidence and picks the highest score,
+// ties go to the language with the strongest single piece of evidence and then to the language
+// found first. The confidence combines how strong the evidence of the picked language is (as if
+// the weights were independent hit rates) with its share of the total score, so contradicting
+// evidence lowers it. It ranks detections, it is no calibrated probability.
 func determineMostLikelyLanguage(info BinaryLanguageInfo) BinaryLanguageInfo {
-	if len(info.PossibleLanguages) == 0 {
+	type languageScore struct {
+		language  string
+		order     int
+		score     float64
+		strongest float64
+		allWrong  float64 // product of the complements of the weights of the evidence of the language
+	}
+	// binaries of other languages link the C runtime as well, so it only counts if nothing else was found
+	onlyBaseline := From(info.WeightedEvidence).AllT(func(e LanguageEvidence) bool { return e.Source == evidenceBaseline })
+
+	scores := map[string]*languageScore{}
+	totalScore := 0.0
+	for _, evidence := range info.WeightedEvidence {
+		if evidence.Source == evidenceBaseline && !onlyBaseline {
+			continue
+		}
+		weight := evidence.Weight / float64(len(evidence.Languages))
+		for _, language := range evidence.Languages {
+			score, found := scores[language]
+			if !found {
+				score = &languageScore{language: language, order: len(scores), allWrong: 1}
+				scores[language] = score
+			}
+			score.score += weight
+			score.strongest = math.Max(score.strongest, weight)
+			score.allWrong *= 1 - weight
+			totalScore += weight
+		}
+	}
+
+	if len(scores) == 0 {
 		info.MostLikelyLanguage = "Unknown"
 		info.Confidence = 0
+		info.PossibleLanguages = nil
 		return info
 	}
 
-	// Count occurrences and assign confidence
-	langCount := make(map[string]int)
-	for _, lang := range info.PossibleLanguages {
-		langCount[lang]++
+	var rankedScores []*languageScore
+	From(scores).
+		SelectT(func(kv KeyValue) *languageScore {
+			return kv.Value.(*languageScore)
+		}).
+		OrderByDescendingT(func(s *languageScore) float64 {
+			return s.score
+		}).
+		ThenByDescendingT(func(s *languageScore) float64 {
+			return s.strongest
+		}).
+		ThenByT(func(s *languageScore) int {
+			return s.order
+		}).
+		ToSlice(&rankedScores)
+
+	best := rankedScores[0]
+	info.MostLikelyLanguage = best.language
+	info.Confidence = (1 - best.allWrong) * best.score / totalScore
+	info.PossibleLanguages = nil
+	for _, s := range rankedScores {
+		info.PossibleLanguages = append(info.PossibleLanguages, s.language)
 	}
 
-	// Find most frequent language
-	maxCount := 0
-	bestLang := ""
-	for lang, count := range langCount {
-		if count > maxCount {
-			maxCount = count
-			bestLang = lang
-		}
-	}
-
-	info.MostLikelyLanguage = bestLang
-	info.Confidence = float64(maxCount) / float64(len(info.PossibleLanguages))
-
 	return info
 }
 
@@ -400,19 +549,22 @@ func isRustBinary(f *pe.File) bool {
 	return false
 }
 
+// returns the DLLs a PE file imports symbols from, each DLL once so a runtime with many imported
+// functions doesn't count as more evidence
 func getPEImports(f *pe.File) []string {
-	if symbols, err := f.ImportedSymbols(); err == nil {
-		return symbols
-	}
-	return []string{}
-}
-
-func hasGoBuildInfo(f *elf.File) bool {
-	// Check for Go build info section
-	if sec := f.Section(".go.buildinfo"); sec != nil {
-		return true
-	}
-	return false
+	symbols, err := f.ImportedSymbols()
+	if err != nil {
+		return []string{}
+	}
+	// imported symbols are formatted as symbol:dll, e.g. memcpy:msvcrt.dll
+	var libraries []string
+	From(symbols).
+		SelectT(func(symbol string) string {
+			return strings.ToLower(symbol[strings.LastIndex(symbol, ":")+1:])
+		}).
+		Distinct().
+		ToSlice(&libraries)
+	return libraries
 }
 
 func hasRustSymbols(f *elf.File) bool {
@@ -425,6 +577,167 @@ func hasRustSymbols(f *elf.File) bool {
 	return false
 }
 
+// returns the exploit mitigations of an executable without detecting its language, e.g. of the runtime
+// of an application, nil for formats other than ELF and PE
+func getExecutableHardening(fsys FileSystem, path string) *BinaryHardening {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	fileType, _, err := detectFileType(file)
+	if err != nil {
+		return nil
+	}
+
+	var hardening BinaryHardening
+	switch fileType {
+	case "ELF":
+		f, err := elf.NewFile(file)
+		if err != nil {
+			return nil
+		}
+		hardening = getElfHardening(f)
+	case "PE":
+		f, err := newPEFile(file)
+		if err != nil {
+			return nil
+		}
+		hardening = getPEHardening(f)
+	default:
+		return nil
+	}
+	return &hardening
+}
+
+func getElfHardening(f *elf.File) BinaryHardening {
+	const dfBindNow = 0x8            // DF_BIND_NOW
+	const df1Now = 0x1               // DF_1_NOW
+	const df1Pie = 0x08000000        // DF_1_PIE
+	const ptGnuProperty = 0x6474e553 // PT_GNU_PROPERTY
+
+	hardening := BinaryHardening{Relro: "none"}
+
+	// Check segments for RELRO, an executable stack and the program interpreter
+	hasInterpreter := false
+	hasGnuStack := false
+	for _, prog := range f.Progs {
+		switch prog.Type {
+		case elf.PT_GNU_RELRO:
+			hardening.Relro = "partial"
+		case elf.PT_GNU_STACK:
+			hasGnuStack = true
+			hardening.Nx = prog.Flags&elf.PF_X == 0
+		case elf.PT_INTERP:
+			hasInterpreter = true
+		case ptGnuProperty:
+			hardening.Ibt, hardening.Shstk = getElfCetFeatures(f, prog)
+		}
+	}
+	if !hasGnuStack {
+		// without GNU_STACK the kernel maps the stack executable
+		hardening.Nx = false
+	}
+
+	// Check dynamic flags for BIND_NOW (full RELRO) and PIE
+	var flags, flags1 uint64
+	if values, err := f.DynValue(elf.DT_FLAGS); err == nil && len(values) > 0 {
+		flags = values[0]
+	}
+	if values, err := f.DynValue(elf.DT_FLAGS_1); err == nil && len(values) > 0 {
+		flags1 = values[0]
+	}
+	bindNow := flags&dfBindNow != 0 || flags1&df1Now != 0
+	if values, err := f.DynValue(elf.DT_BIND_NOW); err == nil && len(values) > 0 {
+		// the legacy DT_BIND_NOW entry has no value, its presence enables it
+		bindNow = true
+	}
+	if hardening.Relro == "partial" && bindNow {
+		hardening.Relro = "full"
+	}
+	// shared libraries are ET_DYN as well, but have no interpreter (except static PIE executables)
+	hardening.Pie = f.Type == elf.ET_DYN && (hasInterpreter || flags1&df1Pie != 0)
+
+	// Check symbols for stack protector and _FORTIFY_SOURCE functions
+	symbols, _ := f.DynamicSymbols()
+	staticSymbols, _ := f.Symbols()
+	for _, symbol := range append(symbols, staticSymbols...) {
+		switch {
+		case symbol.Name == "__stack_chk_fail" || symbol.Name == "__stack_chk_guard":
+			hardening.StackCanary = true
+		case strings.HasPrefix(symbol.Name, "__") && strings.HasSuffix(symbol.Name, "_chk"):
+			hardening.Fortify = true
+		}
+	}
+
+	return hardening
+}
+
+// reads the x86 CET features of the GNU property note, e.g. written by gcc -fcf-protection
+func getElfCetFeatures(f *elf.File, prog *elf.Prog) (bool, bool) {
+	const ntGnuPropertyType0 = 5                 // NT_GNU_PROPERTY_TYPE_0
+	const gnuPropertyX86Feature1And = 0xc0000002 // GNU_PROPERTY_X86_FEATURE_1_AND
+	const featureIbt = 0x1
+	const featureShstk = 0x2
+
+	data := make([]byte, prog.Filesz)
+	if _, err := prog.ReadAt(data, 0); err != nil {
+		return false, false
+	}
+	// properties are aligned to 8 bytes in 64-bit and to 4 bytes in 32-bit objects
+	align := 4
+	if f.Class == elf.ELFCLASS64 {
+		align = 8
+	}
+	alignUp := func(n int) int {
+		return (n + align - 1) &^ (align - 1)
+	}
+
+	for len(data) >= 12 {
+		nameSize := int(f.ByteOrder.Uint32(data[0:4]))
+		descSize := int(f.ByteOrder.Uint32(data[4:8]))
+		noteType := f.ByteOrder.Uint32(data[8:12])
+		// the descriptor is aligned relative to the start of the note, not to the end of the name
+		descStart := alignUp(12 + nameSize)
+		if nameSize > len(data) || descSize > len(data) || descStart+descSize > len(data) {
+			break
+		}
+		if noteType == ntGnuPropertyType0 && nameSize == 4 && string(data[12:15]) == "GNU" {
+			desc := data[descStart : descStart+descSize]
+			for len(desc) >= 8 {
+				propertyType := f.ByteOrder.Uint32(desc[0:4])
+				propertySize := int(f.ByteOrder.Uint32(desc[4:8]))
+				if 8+propertySize > len(desc) {
+					break
+				}
+				if propertyType == gnuPropertyX86Feature1And && propertySize >= 4 {
+					features := f.ByteOrder.Uint32(desc[8:12])
+					return features&featureIbt != 0, features&featureShstk != 0
+				}
+				desc = desc[min(len(desc), 8+alignUp(propertySize)):]
+			}
+		}
+		data = data[min(len(data), alignUp(descStart+descSize)):]
+	}
+	return false, false
+}
+
+func getPEHardening(f *pe.File) BinaryHardening {
+	var dllCharacteristics uint16
+	switch oh := f.OptionalHeader.(type) {
+	case *pe.OptionalHeader32:
+		dllCharacteristics = oh.DllCharacteristics
+	case *pe.OptionalHeader64:
+		dllCharacteristics = oh.DllCharacteristics
+	}
+	return BinaryHardening{
+		Pie:           dllCharacteristics&pe.IMAGE_DLLCHARACTERISTICS_DYNAMIC_BASE != 0,
+		Nx:            dllCharacteristics&pe.IMAGE_DLLCHARACTERISTICS_NX_COMPAT != 0,
+		Cfg:           dllCharacteristics&pe.IMAGE_DLLCHARACTERISTICS_GUARD_CF != 0,
+		HighEntropyVa: dllCharacteristics&pe.IMAGE_DLLCHARACTERISTICS_HIGH_ENTROPY_VA != 0,
+	}
+}
+
 func hasSwiftSections(f *macho.File) bool {
 	// Check for Swift sections
 	for _, s := range f.Sections {
@@ -459,6 +772,41 @@ func hasObjCSections(f *macho.File) bool {
 	return false
 }
 
+// returns the slice of a universal binary matching our architecture, or the first one if none matches
+func selectMachOArchitecture(fatFile *macho.FatFile) *macho.File {
+	for _, arch := range fatFile.Arches {
+		if machOArchitecture(arch.Cpu) == runtime.GOARCH {
+			// analyse our architecture
+			return arch.File
+		}
+	}
+	if len(fatFile.Arches) > 0 {
+		// if no matching arch found, take first
+		return fatFile.Arches[0].File
+	}
+	return nil
+}
+
+// returns the GOARCH of a Mach-O CPU type, empty for CPU types Go does not support
+func machOArchitecture(cpu macho.Cpu) string {
+	switch cpu {
+	case macho.Cpu386:
+		return "386"
+	case macho.CpuAmd64:
+		return "amd64"
+	case macho.CpuArm:
+		return "arm"
+	case macho.CpuArm64:
+		return "arm64"
+	case macho.CpuPpc:
+		return "ppc"
+	case macho.CpuPpc64:
+		return "ppc64"
+	default:
+		return ""
+	}
+}
+
 func getMachOImports(f *macho.File) []string {
 	if libs, err := f.ImportedLibraries(); err == nil {
 		return libs
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"os"
+	"runtime"
+	"testing"
+)
+
+// builds a universal binary of empty Mach-O files for the CPU types
+func buildTestFatFile(t *testing.T, cpus ...macho.Cpu) *macho.FatFile {
+	const fatHeaderSize, fatArchSize, fileSize = 8, 20, 28
+	var data bytes.Buffer
+	binary.Write(&data, binary.BigEndian, []uint32{macho.MagicFat, uint32(len(cpus))})
+	for i, cpu := range cpus {
+		offset := fatHeaderSize + len(cpus)*fatArchSize + i*fileSize
+		binary.Write(&data, binary.BigEndian, []uint32{uint32(cpu), 0, uint32(offset), fileSize, 0})
+	}
+	for _, cpu := range cpus {
+		binary.Write(&data, binary.LittleEndian, macho.FileHeader{Magic: macho.Magic32, Cpu: cpu, Type: macho.TypeExec})
+	}
+	fatFile, err := macho.NewFatFile(bytes.NewReader(data.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fatFile
+}
+
+func TestSelectMachOArchitecture(t *testing.T) {
+	// CPU types Go has no GOARCH for, e.g. of a crafted file, have names shorter than the Cpu prefix
+	const cpuSparc = macho.Cpu(14)
+	if file := selectMachOArchitecture(buildTestFatFile(t, cpuSparc)); file == nil || file.Cpu != cpuSparc {
+		t.Errorf("got %+v, expected the first architecture", file)
+	}
+
+	for _, cpu := range []macho.Cpu{macho.Cpu386, macho.CpuAmd64, macho.CpuArm, macho.CpuArm64, macho.CpuPpc, macho.CpuPpc64} {
+		if machOArchitecture(cpu) != runtime.GOARCH {
+			continue
+		}
+		if file := selectMachOArchitecture(buildTestFatFile(t, cpuSparc, cpu)); file == nil || file.Cpu != cpu {
+			t.Errorf("got %+v, expected the architecture %s", file, runtime.GOARCH)
+		}
+	}
+}
+
+func TestGetElfHardening(t *testing.T) {
+	// see testdata/hardening/build.sh
+	hardened, err := os.ReadFile("testdata/hardening/hardened")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unhardened, err := os.ReadFile("testdata/hardening/unhardened")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// returns a copy of the hardened executable with headers changed by a function, which gets the
+	// file offsets of the program headers by type and of the dynamic entries by tag
+	mutate := func(change func(data []byte, progs map[elf.ProgType]int, dynamic map[elf.DynTag]int)) []byte {
+		f, err := elf.NewFile(bytes.NewReader(hardened))
+		if err != nil {
+			t.Fatal(err)
+		}
+		const phoff, phentsize = 0x20, 0x36 // of the ELF64 header
+		progs := map[elf.ProgType]int{}
+		for i, prog := range f.Progs {
+			progs[prog.Type] = int(binary.LittleEndian.Uint64(hardened[phoff:])) + i*int(binary.LittleEndian.Uint16(hardened[phentsize:]))
+		}
+		dynamic := map[elf.DynTag]int{}
+		section := f.Section(".dynamic")
+		for offset := int(section.Offset); offset < int(section.Offset+section.Size); offset += 16 {
+			dynamic[elf.DynTag(binary.LittleEndian.Uint64(hardened[offset:]))] = offset
+		}
+		changed := bytes.Clone(hardened)
+		change(changed, progs, dynamic)
+		return changed
+	}
+	// offsets of the fields of an ELF64 program header and of the GNU property note, which holds a
+	// single x86 feature property
+	const progType, progOffset, progFilesz = 0, 8, 32
+	const propertyType, propertySize, propertyFeatures = 16, 20, 24
+	propertyNote := func(data []byte, progs map[elf.ProgType]int) int {
+		return int(binary.LittleEndian.Uint64(data[progs[elf.PT_GNU_PROPERTY]+progOffset:]))
+	}
+
+	fullHardening := BinaryHardening{Relro: "full", Pie: true, Nx: true, StackCanary: true, Fortify: true, Ibt: true, Shstk: true}
+	tests := []struct {
+		name     string
+		data     []byte
+		expected BinaryHardening
+	}{
+		{"hardened", hardened, fullHardening},
+		// ET_EXEC with an executable PT_GNU_STACK and lazy binding
+		{"unhardened", unhardened, BinaryHardening{Relro: "partial"}},
+		{"BIND_NOW only in DT_FLAGS_1", mutate(func(data []byte, progs map[elf.ProgType]int, dynamic map[elf.DynTag]int) {
+			binary.LittleEndian.PutUint64(data[dynamic[elf.DT_FLAGS]:], uint64(elf.DT_DEBUG))
+		}), fullHardening},
+		{"no BIND_NOW", mutate(func(data []byte, progs map[elf.ProgType]int, dynamic map[elf.DynTag]int) {
+			binary.LittleEndian.PutUint64(data[dynamic[elf.DT_FLAGS]+8:], 0)
+			binary.LittleEndian.PutUint64(data[dynamic[elf.DT_FLAGS_1]+8:], uint64(elf.DF_1_PIE))
+		}), BinaryHardening{Relro: "partial", Pie: true, Nx: true, StackCanary: true, Fortify: true, Ibt: true, Shstk: true}},
+		// without PT_GNU_STACK the kernel maps the stack executable
+		{"no PT_GNU_STACK", mutate(func(data []byte, progs map[elf.ProgType]int, dynamic map[elf.DynTag]int) {
+			binary.LittleEndian.PutUint32(data[progs[elf.PT_GNU_STACK]+progType:], uint32(elf.PT_NULL))
+		}), BinaryHardening{Relro: "full", Pie: true, StackCanary: true, Fortify: true, Ibt: true, Shstk: true}},
+		{"IBT only", mutate(func(data []byte, progs map[elf.ProgType]int, dynamic map[elf.DynTag]int) {
+			binary.LittleEndian.PutUint32(data[propertyNote(data, progs)+propertyFeatures:], 0x1)
+		}), BinaryHardening{Relro: "full", Pie: true, Nx: true, StackCanary: true, Fortify: true, Ibt: true}},
+		{"other property", mutate(func(data []byte, progs map[elf.ProgType]int, dynamic map[elf.DynTag]int) {
+			binary.LittleEndian.PutUint32(data[propertyNote(data, progs)+propertyType:], 0xc0008002)
+		}), BinaryHardening{Relro: "full", Pie: true, Nx: true, StackCanary: true, Fortify: true}},
+		{"truncated note", mutate(func(data []byte, progs map[elf.ProgType]int, dynamic map[elf.DynTag]int) {
+			binary.LittleEndian.PutUint64(data[progs[elf.PT_GNU_PROPERTY]+progFilesz:], 22)
+		}), BinaryHardening{Relro: "full", Pie: true, Nx: true, StackCanary: true, Fortify: true}},
+		{"property beyond the note", mutate(func(data []byte, progs map[elf.ProgType]int, dynamic map[elf.DynTag]int) {
+			binary.LittleEndian.PutUint32(data[propertyNote(data, progs)+propertySize:], 0xfffffff0)
+		}), BinaryHardening{Relro: "full", Pie: true, Nx: true, StackCanary: true, Fortify: true}},
+	}
+	for _, test := range tests {
+		f, err := elf.NewFile(bytes.NewReader(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if hardening := getElfHardening(f); hardening != test.expected {
+			t.Errorf("%s: got %+v, expected %+v", test.name, hardening, test.expected)
+		}
+	}
+}
+
+func TestGetPEHardening(t *testing.T) {
+	data, err := os.ReadFile("testdata/language-corpus/hello-csharp.dll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// DllCharacteristics is at the same offset of the optional header of PE32 and PE32+ files
+	dllCharacteristics := int(binary.LittleEndian.Uint32(data[0x3c:])) + 24 + 70
+
+	tests := []struct {
+		flags    uint16
+		expected BinaryHardening
+	}{
+		{0, BinaryHardening{}},
+		{pe.IMAGE_DLLCHARACTERISTICS_DYNAMIC_BASE, BinaryHardening{Pie: true}},
+		{pe.IMAGE_DLLCHARACTERISTICS_DYNAMIC_BASE | pe.IMAGE_DLLCHARACTERISTICS_HIGH_ENTROPY_VA, BinaryHardening{Pie: true, HighEntropyVa: true}},
+		{pe.IMAGE_DLLCHARACTERISTICS_NX_COMPAT, BinaryHardening{Nx: true}},
+		{pe.IMAGE_DLLCHARACTERISTICS_GUARD_CF, BinaryHardening{Cfg: true}},
+		{pe.IMAGE_DLLCHARACTERISTICS_DYNAMIC_BASE | pe.IMAGE_DLLCHARACTERISTICS_HIGH_ENTROPY_VA |
+			pe.IMAGE_DLLCHARACTERISTICS_NX_COMPAT | pe.IMAGE_DLLCHARACTERISTICS_GUARD_CF,
+			BinaryHardening{Pie: true, Nx: true, Cfg: true, HighEntropyVa: true}},
+	}
+	for _, test := range tests {
+		changed := bytes.Clone(data)
+		binary.LittleEndian.PutUint16(changed[dllCharacteristics:], test.flags)
+		f, err := pe.NewFile(bytes.NewReader(changed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hardening := getPEHardening(f); hardening != test.expected {
+			t.Errorf("%#x: got %+v, expected %+v", test.flags, hardening, test.expected)
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"debug/macho"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// load commands referencing dylibs, debug/macho only decodes LC_LOAD_DYLIB
+const (
+	machoLoadCmdDylib       macho.LoadCmd = 0xc
+	machoLoadCmdLazyDylib   macho.LoadCmd = 0x20
+	machoLoadCmdWeakDylib   macho.LoadCmd = 0x80000018
+	machoLoadCmdRpath       macho.LoadCmd = 0x8000001c
+	machoLoadCmdReexport    macho.LoadCmd = 0x8000001f
+	machoLoadCmdUpwardDylib macho.LoadCmd = 0x80000023
+)
+
+// machoDylib is a dylib referenced by a load command
+type machoDylib struct {
+	name string
+	weak bool // missing weak dylibs are ignored by dyld
+}
+
+// machoObject holds the load commands of a Mach-O file needed to resolve its dependencies
+type machoObject struct {
+	path   string
+	dylibs []machoDylib
+	rpaths []string // LC_RPATH entries with @loader_path and @executable_path already expanded
+}
+
+type queuedMachOObject struct {
+	object     *machoObject
+	rpathChain []string // LC_RPATH entries of the object and the objects that loaded it
+}
+
+// resolves the transitive LC_LOAD_DYLIB dependencies of a Mach-O executable the same way dyld does,
+// this works on any OS, e.g. with a .app bundle copied to Linux
+func resolveMachODependencies(fsys FileSystem, exePath string) (LibraryDependencyGraph, error) {
+	graph := LibraryDependencyGraph{
+		Executable:   exePath,
+		Dependencies: map[string][]LibraryDependency{},
+	}
+
+	executablePath := exePath
+	if realPath, err := fsys.EvalSymlinks(exePath); err == nil {
+		executablePath = realPath
+	}
+	executable, err := openMachOObject(fsys, executablePath, executablePath)
+	if err != nil {
+		return graph, err
+	}
+	graph.Executable = executable.path
+
+	loadedByPath := map[string]bool{executable.path: true}
+	queue := []queuedMachOObject{{object: executable, rpathChain: executable.rpaths}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		for _, dylib := range current.object.dylibs {
+			dependency := LibraryDependency{
+				Name:     dylib.name,
+				NeededBy: current.object.path,
+			}
+			path, resolvedBy := findMachOLibrary(fsys, dylib.name, current, executable.path)
+			if path == "" {
+				if !dylib.weak {
+					graph.Unresolved = append(graph.Unresolved, dependency)
+				}
+				graph.Dependencies[current.object.path] = append(graph.Dependencies[current.object.path], dependency)
+				continue
+			}
+			dependency.Path = path
+			dependency.ResolvedBy = resolvedBy
+			graph.Dependencies[current.object.path] = append(graph.Dependencies[current.object.path], dependency)
+
+			if loadedByPath[path] {
+				continue
+			}
+			loadedByPath[path] = true
+			graph.Libraries = append(graph.Libraries, path)
+
+			if resolvedBy == "dyld shared cache" {
+				// the load commands of cached libraries are not available on disk
+				continue
+			}
+			library, err := openMachOObject(fsys, path, executable.path)
+			if err != nil {
+				continue
+			}
+			rpathChain := append(append([]string{}, library.rpaths...), current.rpathChain...)
+			queue = append(queue, queuedMachOObject{object: library, rpathChain: rpathChain})
+		}
+	}
+
+	return graph, nil
+}
+
+// expands the @executable_path, @loader_path and @rpath prefixes of a dylib name, see dyld(1)
+func findMachOLibrary(fsys FileSystem, name string, loader queuedMachOObject, executablePath string) (string, string) {
+	var candidates []string
+	var resolvedBy string
+	switch {
+	case strings.HasPrefix(name, "@executable_path/"):
+		candidates = []string{filepath.Join(filepath.Dir(executablePath), strings.TrimPrefix(name, "@executable_path/"))}
+		resolvedBy = "@executable_path"
+	case strings.HasPrefix(name, "@loader_path/"):
+		candidates = []string{filepath.Join(filepath.Dir(loader.object.path), strings.TrimPrefix(name, "@loader_path/"))}
+		resolvedBy = "@loader_path"
+	case strings.HasPrefix(name, "@rpath/"):
+		for _, rpath := range loader.rpathChain {
+			candidates = append(candidates, filepath.Join(rpath, strings.TrimPrefix(name, "@rpath/")))
+		}
+		resolvedBy = "LC_RPATH"
+	default:
+		candidates = []string{name}
+		resolvedBy = "install name"
+	}
+
+	for _, candidate := range candidates {
+		if _, err := fsys.Stat(candidate); err != nil {
+			continue
+		}
+		if realPath, err := fsys.EvalSymlinks(candidate); err == nil {
+			return realPath, resolvedBy
+		}
+		return candidate, resolvedBy
+	}
+
+	// as of macOS Big Sur (11) core system libraries only exist in the dyld shared cache
+	if _, isHost := fsys.(hostFileSystem); isHost && runtime.GOOS == "darwin" {
+		for _, candidate := range candidates {
+			if _, err := getDyldSharedCacheTextSize(candidate); err == nil {
+				return candidate, "dyld shared cache"
+			}
+		}
+	}
+
+	return "", ""
+}
+
+// reads the dylib and rpath load commands of a Mach-O file, universal binaries are read from the
+// slice matching our architecture
+func openMachOObject(fsys FileSystem, path string, executablePath string) (*machoObject, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var machoFile *macho.File
+	fatFile, err := macho.NewFatFile(file)
+	switch err {
+	case nil:
+		defer fatFile.Close()
+		machoFile = selectMachOArchitecture(fatFile)
+	case macho.ErrNotFat:
+		machoFile, err = macho.NewFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open Mach-O file %s: %v", path, err)
+		}
+		defer machoFile.Close()
+	default:
+		return nil, fmt.Errorf("failed to open Mach-O file %s: %v", path, err)
+	}
+
+	object := &machoObject{path: path}
+	for _, load := range machoFile.Loads {
+		raw := load.Raw()
+		if len(raw) < 12 {
+			continue
+		}
+		// all of these load commands store the offset of their string after cmd and cmdsize
+		cmd := macho.LoadCmd(machoFile.ByteOrder.Uint32(raw[0:4]))
+		offset := machoFile.ByteOrder.Uint32(raw[8:12])
+		if int(offset) >= len(raw) {
+			continue
+		}
+		value, _, _ := bytes.Cut(raw[offset:], []byte{0})
+
+		switch cmd {
+		case machoLoadCmdDylib, machoLoadCmdLazyDylib, machoLoadCmdReexport, machoLoadCmdUpwardDylib:
+			object.dylibs = append(object.dylibs, machoDylib{name: string(value)})
+		case machoLoadCmdWeakDylib:
+			object.dylibs = append(object.dylibs, machoDylib{name: string(value), weak: true})
+		case machoLoadCmdRpath:
+			// rpaths are relative to the object declaring them, not to the one using them
+			rpath := string(value)
+			switch {
+			case strings.HasPrefix(rpath, "@loader_path"):
+				rpath = filepath.Join(filepath.Dir(path), strings.TrimPrefix(rpath, "@loader_path"))
+			case strings.HasPrefix(rpath, "@executable_path"):
+				rpath = filepath.Join(filepath.Dir(executablePath), strings.TrimPrefix(rpath, "@executable_path"))
+			}
+			object.rpaths = append(object.rpaths, rpath)
+		}
+	}
+
+	return object, nil
+}
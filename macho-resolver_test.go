@@ -0,0 +1,64 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveMachODependencies(t *testing.T) {
+	// a .app bundle copied to Linux, see testdata/macho/build.sh
+	fsys := rootFileSystem{root: "testdata/macho"}
+	graph, err := resolveMachODependencies(fsys, "/Hello.app/Contents/MacOS/Hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const contents = "/Hello.app/Contents"
+	const greeting = contents + "/Frameworks/Greeting.framework/Versions/A/Greeting"
+	expectedLibraries := []string{
+		greeting,
+		contents + "/Frameworks/libutil.dylib",
+		contents + "/Frameworks/libhelper.dylib",
+		contents + "/Frameworks/Greeting.framework/Versions/A/Libraries/libdeep.dylib",
+	}
+	if !reflect.DeepEqual(graph.Libraries, expectedLibraries) {
+		t.Errorf("got libraries %v, expected %v", graph.Libraries, expectedLibraries)
+	}
+
+	expectedResolvedBy := map[string]string{
+		"@rpath/Greeting.framework/Greeting":           "LC_RPATH",
+		"@executable_path/../Frameworks/libutil.dylib": "@executable_path",
+		"@loader_path/../../../libhelper.dylib":        "@loader_path",
+		// the rpath of the framework, not the one of the executable
+		"@rpath/libdeep.dylib": "LC_RPATH",
+		// weak dylibs that are missing are ignored
+		"@rpath/libmissing.dylib": "",
+		// only in the dyld shared cache on macOS
+		"/usr/lib/libSystem.B.dylib": "",
+	}
+	for _, dependencies := range graph.Dependencies {
+		for _, dependency := range dependencies {
+			expected, ok := expectedResolvedBy[dependency.Name]
+			if !ok {
+				t.Errorf("unexpected dependency %s of %s", dependency.Name, dependency.NeededBy)
+			} else if dependency.ResolvedBy != expected {
+				t.Errorf("%s resolved by %q, expected %q", dependency.Name, dependency.ResolvedBy, expected)
+			}
+		}
+	}
+
+	var unresolved []string
+	for _, dependency := range graph.Unresolved {
+		unresolved = append(unresolved, dependency.Name+" of "+dependency.NeededBy)
+	}
+	expectedUnresolved := []string{
+		"/usr/lib/libSystem.B.dylib of " + contents + "/MacOS/Hello",
+		"/usr/lib/libSystem.B.dylib of " + greeting,
+		"/usr/lib/libSystem.B.dylib of " + contents + "/Frameworks/libutil.dylib",
+		"/usr/lib/libSystem.B.dylib of " + contents + "/Frameworks/libhelper.dylib",
+		"/usr/lib/libSystem.B.dylib of " + contents + "/Frameworks/Greeting.framework/Versions/A/Libraries/libdeep.dylib",
+	}
+	if !reflect.DeepEqual(unresolved, expectedUnresolved) {
+		t.Errorf("got unresolved %v, expected %v", unresolved, expectedUnresolved)
+	}
+}
@@ -1,237 +1,454 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
-	"os/exec"
-	"regexp"
 	"runtime"
 	"strings"
 
-	"code.cloudfoundry.org/bytefmt"         // to convert bytes into/from human-readable format
 	. "github.com/ahmetb/go-linq"           // LINQ for Go to manage data structure like its 2025
 	"github.com/shirou/gopsutil/v4/process" // to get process information
 )
 
-var (
-	gLibraryNameRegex *regexp.Regexp
-)
-
+// ProcessInfo holds the analysis results of a process, the JSON representation is part of the
+// versioned report schema, see reportSchemaVersion
 type ProcessInfo struct {
-	pid                      int32
-	name                     string
-	user_id                  int
-	executable_path          string
-	executable_size_in_bytes int64
-	libraries_size_in_bytes  int64
-	detected_language        string
+	Pid                          int32                   `json:"pid"` // 0 for binaries of an offline scan
+	Name                         string                  `json:"name"`
+	UserId                       int                     `json:"uid"` // -1 for binaries of an offline scan
+	ExecutablePath               string                  `json:"path"`
+	ApplicationPath              string                  `json:"application_path"`         // application run by a runtime like dotnet or python, its module or main class if it has no file
+	ExecutableSizeInBytes        int64                   `json:"executable_size_in_bytes"` // size of the executable code only
+	ExecutableFileSizeInBytes    int64                   `json:"executable_file_size_in_bytes"`
+	ExecutableSizeMethod         string                  `json:"executable_size_method"`           // how the size of the executable code was measured
+	ExecutablePayloadSizeInBytes int64                   `json:"executable_payload_size_in_bytes"` // scripts and assets embedded in the executable, e.g. of a Node SEA
+	LibrariesSizeInBytes         int64                   `json:"libraries_size_in_bytes"`          // size of the executable code only
+	LibrariesFileSizeInBytes     int64                   `json:"libraries_file_size_in_bytes"`
+	DetectedLanguage             string                  `json:"language"`
+	LanguageConfidence           float64                 `json:"confidence"` // ranks detections between 0 and 1, no calibrated probability
+	LanguageEvidence             []string                `json:"evidence"`
+	WeightedLanguageEvidence     []LanguageEvidence      `json:"weighted_evidence"`
+	MemorySafety                 MemorySafety            `json:"memory_safety"`
+	Hardening                    *BinaryHardening        `json:"hardening"`               // null for formats other than ELF and PE
+	GoBuildInfo                  *GoBuildInfo            `json:"go_build_info"`           // null for binaries of other languages
+	RustBuildInfo                *RustBuildInfo          `json:"rust_build_info"`         // null for binaries of other languages
+	PythonBundle                 *PythonBundle           `json:"python_bundle"`           // null for executables other than frozen Python applications
+	JavaScriptBundle             *JavaScriptBundle       `json:"javascript_bundle"`       // null for executables other than compiled JavaScript applications
+	DotNetAssembly               *DotNetAssembly         `json:"dotnet_assembly"`         // null for executables other than .NET assemblies
+	InterpretedApplication       *InterpretedApplication `json:"interpreted_application"` // null for executables other than interpreters
+	Libraries                    []LibraryInfo           `json:"libraries"`
+	MemoryMappings               []MemoryMapping         `json:"memory_mappings"` // file-backed mappings of running processes on Linux
+	IsSetuid                     bool                    `json:"setuid"`
+	Status                       *ProcessStatus          `json:"status"` // null for binaries that are not running or on other OSes than Linux
+	Privilege                    string                  `json:"privilege"`
+	ListeningSockets             []ListeningSocket       `json:"listening_sockets"`
+	UnixSockets                  []UnixSocket            `json:"unix_sockets"`
+	Container                    ContainerInfo           `json:"container"`
+	RiskScore                    RiskScore               `json:"risk_score"`
+}
+
+// LibraryInfo describes a shared library of a process and how it got loaded
+type LibraryInfo struct {
+	Path            string `json:"path"`
+	Origin          string `json:"origin"`        // "linked" for dependencies of the executable, "runtime" for dlopen()ed libraries, "application" for the code of an application run by a runtime or interpreter, "managed" for assemblies of .NET applications, "packages" for package directories of interpreted applications
+	SizeInBytes     int64  `json:"size_in_bytes"` // size of the executable code only
+	SizeMethod      string `json:"size_method"`   // how the size of the executable code was measured
+	FileSizeInBytes int64  `json:"file_size_in_bytes"`
 }
 
 func main() {
-	fmt.Println("Let's hunt for the elephant in the room...")
+	// the scan command analyses the binaries of a directory tree instead of the running processes
+	isScanCommand := len(os.Args) > 1 && os.Args[1] == "scan"
+	arguments := os.Args[1:]
+	if isScanCommand {
+		arguments = os.Args[2:]
+	}
+
+	riskWeightsPath := flag.String("risk-weights", "", "JSON file with the weights of the risk exposure score factors")
+	format := flag.String("format", "text", "report format: text or json")
+	details := flag.Bool("details", false, "print a break-down of the libraries of each executable (text format only)")
+	rootDirectory := flag.String("root", "", "directory to scan, e.g. an extracted root file system (scan command only)")
+	imagePath := flag.String("image", "", "OCI image layout or docker save tarball to scan (scan command only)")
+	flag.CommandLine.Parse(arguments)
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "Error: unsupported report format: %s\n", *format)
+		os.Exit(2)
+	}
+	if isScanCommand != (*rootDirectory != "" || *imagePath != "") || (*rootDirectory != "" && *imagePath != "") {
+		fmt.Fprintf(os.Stderr, "Error: usage: %s scan --root <dir> | --image <oci-layout-or-tarball>\n", os.Args[0])
+		os.Exit(2)
+	}
+
+	riskWeights := defaultRiskWeights
+	if *riskWeightsPath != "" {
+		var err error
+		riskWeights, err = loadRiskWeights(*riskWeightsPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading risk weights: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// progress and warnings go to stderr, so stdout only contains the report
+	fmt.Fprintln(os.Stderr, "Let's hunt for the elephant in the room...")
+
+	var processInfos []ProcessInfo
+	var err error
+	switch {
+	case *rootDirectory != "":
+		processInfos, err = scanRootDirectory(*rootDirectory, riskWeights)
+	case *imagePath != "":
+		processInfos, err = scanContainerImage(*imagePath, riskWeights)
+	default:
+		processInfos, err = analyseRunningProcesses(riskWeights)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// use LINQ to sort the list by risk exposure score
+	var sortedProcessInfos []ProcessInfo
+	From(processInfos).
+		OrderByDescendingT(func(p ProcessInfo) float64 {
+			return p.RiskScore.Total
+		}).
+		ToSlice(&sortedProcessInfos)
 
+	// TODO: remove duplicate executables running as the same user, as these don't increase the attack-surface.
+	// same executable running as different users do increase the exposure though as more data is at risk.
+
+	switch *format {
+	case "json":
+		err = printJsonReport(os.Stdout, sortedProcessInfos)
+	default:
+		printTextReport(os.Stdout, sortedProcessInfos, *details)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// analyses the executables and libraries of all running processes
+func analyseRunningProcesses(riskWeights RiskWeights) ([]ProcessInfo, error) {
 	// get a list of all running processes
 	processes, err := process.Processes()
 	if err != nil {
-		fmt.Printf("Error getting processes: %v\n", err)
-		return
+		return nil, fmt.Errorf("failed to get processes: %v", err)
 	}
-	fmt.Printf("Analysing %d running processes...\n", len(processes))
+	fmt.Fprintf(os.Stderr, "Analysing %d running processes...\n", len(processes))
 
 	// analyse each process
 	processInfos := []ProcessInfo{}
 	for _, proc := range processes {
-		procInfo := ProcessInfo{pid: proc.Pid}
-		procInfo.name, _ = proc.Name()
-		procInfo.executable_path, _ = proc.Exe()
+		procInfo := ProcessInfo{Pid: proc.Pid}
+		procInfo.Name, _ = proc.Name()
+		procInfo.ExecutablePath, _ = proc.Exe()
 		user_ids, _ := proc.Uids()
 		if len(user_ids) > 0 {
-			procInfo.user_id = int(user_ids[0])
+			procInfo.UserId = int(user_ids[0])
 		}
 
-		if procInfo.executable_path == "" {
+		if procInfo.ExecutablePath == "" {
 			// ignore internal processes
-			fmt.Printf("WARNING: process with pid: %d has no executable_path\n", procInfo.pid)
+			fmt.Fprintf(os.Stderr, "WARNING: process with pid: %d has no executable_path\n", procInfo.Pid)
 			continue
 		}
 
 		/*
-		if procInfo.name != "dotnet" && procInfo.name != "mono" && procInfo.name != "mono-sgen" {
-			continue
-		}
+			if procInfo.Name != "dotnet" && procInfo.Name != "mono" && procInfo.Name != "mono-sgen" {
+				continue
+			}
 		*/
 
+		// binaries of containers and their libraries are read from the file system of the container
+		fsys := FileSystem(hostFileSystem{})
+		if runtime.GOOS == "linux" {
+			procInfo.Container, err = getContainerInfo(procInfo.Pid)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: could not get container of pid: %d: %s\n", procInfo.Pid, err)
+			} else if !procInfo.Container.SharesHostMountNamespace {
+				fsys = rootFileSystem{root: fmt.Sprintf("/proc/%d/root", procInfo.Pid)}
+			}
+		}
+
+		// runtimes like dotnet and interpreters like python run the application given on their
+		// command line, e.g. dotnet app.dll or python3 app.py
+		args, _ := proc.CmdlineSlice()
+		cwd, _ := proc.Cwd()
+		interpreter, interpretedApplication := getProcessApplication(&procInfo, args, cwd)
+
 		// don't analyse same binary running as same user again (a priv process still has higher risk)
 		isProcessedAlreadyAnalysed := From(processInfos).CountWithT(
 			func(p ProcessInfo) bool {
-				return p.executable_path == procInfo.executable_path &&
-					p.user_id == procInfo.user_id
+				return p.ExecutablePath == procInfo.ExecutablePath &&
+					p.ApplicationPath == procInfo.ApplicationPath &&
+					p.UserId == procInfo.UserId &&
+					p.Container.MountNamespace == procInfo.Container.MountNamespace
 			}) > 0
 		if isProcessedAlreadyAnalysed {
 			continue
 		}
 
-		fileInfo, err := os.Stat(procInfo.executable_path)
-		if err == nil {
-			procInfo.executable_size_in_bytes = fileInfo.Size()
-		}
+		analyseExecutableSize(fsys, &procInfo)
 
-		fmt.Printf("analysing executable: %s...\n", procInfo.executable_path)
+		fmt.Fprintf(os.Stderr, "analysing executable: %s...\n", procInfo.ExecutablePath)
 
 		// analyze the language the binary was probably written in
-		switch {
-		case procInfo.name == "dotnet":
-			fallthrough
-		case procInfo.name == "mono":
-			fallthrough
-		case procInfo.name == "mono-sgen":
-			cmd, _ := proc.Cmdline()
-			// if this application is using the mono or dotnet runtime then the .NET executable
-			// or library filename must be a commandline argument
-			if strings.Contains(cmd, ".exe") || strings.Contains(cmd, ".dll") {
-				procInfo.detected_language = ".NET"
-			}
-		default:
-			languageInfo, err := DetectSourceLanguageFromBinary(procInfo.executable_path)
-			if err == nil {
-				procInfo.detected_language = languageInfo.MostLikelyLanguage
-			}
-		}
+		environment, _ := proc.Environ()
+		applicationCode := analyseProcessLanguage(fsys, &procInfo, interpreter, interpretedApplication, args, cwd, environment)
 
 		// analyze dynamically linked and loaded libraries into memory that increase the attack-surface
-		procInfo.libraries_size_in_bytes = 0
-		libraries, err := getDynamicLibraries(procInfo.executable_path)
+		secureExecution := isSecureExecutable(fsys, procInfo.ExecutablePath)
+		if runtime.GOOS == "linux" {
+			if secure, err := getProcessSecureExecution(procInfo.Pid); err == nil {
+				secureExecution = secure
+			}
+		}
+		libraries, err := getDynamicLibraries(fsys, procInfo.ExecutablePath, environment, secureExecution)
 		if err != nil {
-			fmt.Printf("WARNING: could not get libraries: %s\n", err)
+			fmt.Fprintf(os.Stderr, "WARNING: could not get libraries: %s\n", err)
 			continue
 		}
+		linkedLibraries := map[string]bool{}
 		for _, library := range libraries {
-			//fmt.Printf("library: %s\n", library)
-			librarySize, err := getDynamicLibrarySize(library)
+			linkedLibraries[library] = true
+			procInfo.Libraries = append(procInfo.Libraries, LibraryInfo{Path: library, Origin: "linked"})
+		}
+
+		// analyse libraries that were loaded at runtime with dlopen(), e.g. plugins, NSS modules or
+		// JIT runtimes, these never show up as dependency of the executable
+		if runtime.GOOS == "linux" {
+			mappings, err := getMemoryMappings(procInfo.Pid)
 			if err != nil {
-				fmt.Printf("WARNING: %v\n", err)
-				continue
+				fmt.Fprintf(os.Stderr, "WARNING: could not get memory mappings: %s\n", err)
+			}
+			procInfo.MemoryMappings = mappings
+			for _, mappedFile := range getExecutableMappedFiles(mappings) {
+				if mappedFile == procInfo.ExecutablePath || linkedLibraries[mappedFile] {
+					continue
+				}
+				procInfo.Libraries = append(procInfo.Libraries, LibraryInfo{Path: mappedFile, Origin: "runtime"})
 			}
-			procInfo.libraries_size_in_bytes += librarySize
 		}
 
-		// TODO: analyse dynamically dlopen()ed libraries, with lsof -p $PID perhaps?
+		// code of the application run by a runtime or interpreter, unless it got mapped executable already
+		for _, code := range applicationCode {
+			isMapped := From(procInfo.Libraries).AnyWithT(func(l LibraryInfo) bool { return l.Path == code.Path })
+			if !isMapped {
+				procInfo.Libraries = append(procInfo.Libraries, code)
+			}
+		}
 
-		// TODO: analyse listening UDP/TCP ports
-		// lsof -i4 -i6 -nP
+		analyseLibrarySizes(fsys, &procInfo)
 
-		// TODO: detect memory-(un)safe languages an bump risk score
-		// otool -L path_to_app | grep libc++.1.dylib -> C++
-		// nm -g /nix/store/dq249g0b6iqjh3xfjc08gqy2h1590x44-alacritty-0.13.2/bin/alacritty | grep rust_panic -> rust
-		// otool -L path_to_app| grep libswiftCore.dylib -> Swift
-		// nm -g path_to_app | grep swift_stdlib -> Swift
-		// nm -U path_to_app | grep runtime.goPanic -> Go
+		// analyse privileges beyond the user id, a non-root process may still hold e.g. CAP_SYS_ADMIN
+		// and a root process may be locked down with seccomp
+		if runtime.GOOS == "linux" {
+			procInfo.Status, err = getProcessStatus(procInfo.Pid)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: could not get status: %s\n", err)
+			}
+		}
+		procInfo.Privilege = classifyPrivilege(procInfo)
+
+		// analyse listening UDP/TCP ports, which are entry-points for remote attackers
+		procInfo.ListeningSockets, err = getListeningSockets(proc)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: could not get listening sockets: %s\n", err)
+		}
+
+		// analyse listening Unix sockets, which are entry-points for local attackers
+		if runtime.GOOS == "linux" {
+			procInfo.UnixSockets, err = getUnixSockets(fsys, procInfo.Pid)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: could not get Unix sockets: %s\n", err)
+			}
+		}
+
+		// classify memory-(un)safe languages, including memory-safe languages using C/C++ code
+		procInfo.MemorySafety = classifyMemorySafety(procInfo)
+
+		procInfo.RiskScore = calculateRiskScore(procInfo, riskWeights)
 
 		processInfos = append(processInfos, procInfo)
 	}
 
-	// use LINQ to sort the list by size
-	var sortedProcessInfos []ProcessInfo
-	From(processInfos).
-		OrderByDescendingT(func(p ProcessInfo) int {
-			return int(p.executable_size_in_bytes + p.libraries_size_in_bytes)
-		}).
-		ToSlice(&sortedProcessInfos)
+	return processInfos, nil
+}
 
-	// TODO: calculate a risk exposure score
-	// TODO: executable running as uid 0 has higher risk score
+// analyses the setuid bit and the code and file size of the executable
+func analyseExecutableSize(fsys FileSystem, info *ProcessInfo) {
+	if fileInfo, err := fsys.Stat(info.ExecutablePath); err == nil {
+		info.IsSetuid = fileInfo.Mode()&os.ModeSetuid != 0
+	}
+	info.ExecutableFileSizeInBytes, _ = getFileSize(fsys, info.ExecutablePath)
+	var err error
+	info.ExecutableSizeInBytes, info.ExecutableSizeMethod, err = getCodeSize(fsys, info.ExecutablePath)
+	if err != nil {
+		info.ExecutableSizeInBytes = info.ExecutableFileSizeInBytes
+		info.ExecutableSizeMethod = sizeMethodFileSize
+	}
+}
 
-	// TODO: remove duplicate executables running as the same user, as these don't increase the attack-surface.
-	// same executable running as different users do increase the exposure though as more data is at risk.
+// returns if an executable is the runtime of .NET applications
+func isDotNetRuntime(name string) bool {
+	return name == "dotnet" || name == "mono" || name == "mono-sgen"
+}
 
-	for _, info := range sortedProcessInfos {
-		var displayedName string
-		if info.name == "" {
-			displayedName = "N/A"
-		} else {
-			displayedName = info.name
+// detects the application a runtime like dotnet or an interpreter like python runs and sets it as
+// ApplicationPath, e.g. app.dll of `dotnet app.dll`, paths of the command line are relative to the
+// working directory of the process, the interpreter is nil for other executables
+func getProcessApplication(info *ProcessInfo, args []string, cwd string) (*interpreter, InterpretedApplication) {
+	var application InterpretedApplication
+	if len(args) == 0 {
+		// the command line is unknown, e.g. binaries of an offline scan
+		return nil, application
+	}
+	interpreter := getInterpreter(info.ExecutablePath)
+	switch {
+	case isDotNetRuntime(info.Name):
+		if assembly, _ := getDotNetEntryAssembly(args); assembly != "" {
+			info.ApplicationPath = resolveProcessPath(assembly, cwd)
 		}
+	case interpreter != nil:
+		application = interpreter.parseCommandLine(args, cwd)
+		info.ApplicationPath = application.Path
+		if info.ApplicationPath == "" {
+			info.ApplicationPath = application.Module
+		}
+	}
+	return interpreter, application
+}
+
+// analyses the language of a process, which is the one of the application for runtimes and
+// interpreters, and returns the code of the application
+func analyseProcessLanguage(fsys FileSystem, info *ProcessInfo, interpreter *interpreter, application InterpretedApplication,
+	args []string, cwd string, environment []string) []LibraryInfo {
+	switch {
+	case info.ApplicationPath != "" && isDotNetRuntime(info.Name):
+		// the .NET assembly is analysed instead of the runtime, commands of the .NET SDK like
+		// `dotnet build` have no assembly on their command line and are analysed as binary, the
+		// exploit mitigations are still those of the runtime executable
+		info.Hardening = getExecutableHardening(fsys, info.ExecutablePath)
+		return analyseDotNetApplication(fsys, info, args, cwd, environment)
+	case interpreter != nil:
+		// the language of the application, not of the interpreter, whose exploit mitigations are reported
+		info.Hardening = getExecutableHardening(fsys, info.ExecutablePath)
+		return analyseInterpretedApplication(fsys, info, *interpreter, application, args, cwd, environment)
+	}
+	analyseExecutableLanguage(fsys, info)
+	return nil
+}
 
-		var displayedLanguage string
-		if info.detected_language == "" {
-			displayedLanguage = "N/A"
-		} else {
-			displayedLanguage = info.detected_language
+// analyses the language the executable was probably written in
+func analyseExecutableLanguage(fsys FileSystem, info *ProcessInfo) {
+	languageInfo, err := DetectSourceLanguageFromFileSystem(fsys, info.ExecutablePath)
+	if err == nil {
+		info.DetectedLanguage = languageInfo.MostLikelyLanguage
+		info.LanguageConfidence = languageInfo.Confidence
+		info.LanguageEvidence = languageInfo.Evidence
+		info.WeightedLanguageEvidence = languageInfo.WeightedEvidence
+		info.Hardening = languageInfo.Hardening
+		info.GoBuildInfo = languageInfo.GoBuildInfo
+		info.RustBuildInfo = languageInfo.RustBuildInfo
+		info.PythonBundle = languageInfo.PythonBundle
+		info.JavaScriptBundle = languageInfo.JavaScriptBundle
+		info.DotNetAssembly = languageInfo.DotNetAssembly
+		if languageInfo.JavaScriptBundle != nil {
+			// the embedded scripts are code of the process as well, but not measured like machine code
+			info.ExecutablePayloadSizeInBytes = languageInfo.JavaScriptBundle.PayloadSizeInBytes
 		}
+	}
+}
 
-		fmt.Printf("PID: %6d | UID: %3d | Size: %3.1f/%3.1f MB | Name: %s | Lang: %s | Executable Path: %s \n",
-			info.pid, info.user_id,
-			float64(info.executable_size_in_bytes)/1024/1024,
-			float64(info.libraries_size_in_bytes)/1024/1024,
-			displayedName, displayedLanguage, info.executable_path)
+// analyses the code and file size of all libraries
+func analyseLibrarySizes(fsys FileSystem, info *ProcessInfo) {
+	info.LibrariesSizeInBytes = 0
+	for i, library := range info.Libraries {
+		librarySize, sizeMethod, err := getDynamicLibrarySize(fsys, library.Path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "WARNING: %v\n", err)
+			continue
+		}
+		info.Libraries[i].SizeInBytes = librarySize
+		info.Libraries[i].SizeMethod = sizeMethod
+		info.LibrariesSizeInBytes += librarySize
+
+		libraryFileSize, _ := getFileSize(fsys, library.Path)
+		info.Libraries[i].FileSizeInBytes = libraryFileSize
+		info.LibrariesFileSizeInBytes += libraryFileSize
 	}
 }
 
 // retrieves the list of dynamically loaded libraries for an executable
-func getDynamicLibraries(exePath string) ([]string, error) {
+func getDynamicLibraries(fsys FileSystem, exePath string, environment []string, secureExecution bool) ([]string, error) {
 	if exePath == "" {
 		return nil, fmt.Errorf("no executable path provided")
 	}
 
-	// Use `ldd` on Linux or `otool -L` on macOS
-	os := runtime.GOOS
-	var cmd *exec.Cmd
-	switch os {
-	case "linux":
-		cmd = exec.Command("ldd", exePath)
-	case "darwin":
-		cmd = exec.Command("otool", "-L", exePath)
-	default:
-		return nil, fmt.Errorf("unsupported OS: %s", os)
+	file, err := fsys.Open(exePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve libraries: %v", err)
 	}
-
-	// Run the command and capture the output
-	output, err := cmd.Output()
+	fileType, _, err := detectFileType(file)
+	file.Close()
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve libraries: %v", err)
 	}
 
-	// Parse the output
-	lines := strings.Split(string(output), "\n")
-	var libraries []string
-	if gLibraryNameRegex == nil {
-		// initialize static regex instance
-		// use regex to match and remove the compatibility information in parentheses that Darwin's otool outputs, e.g.
-		// /usr/lib/libobjc.A.dylib (compatibility version 1.0.0, current version 228.0.0)
-		gLibraryNameRegex = regexp.MustCompile(`\s*\([^)]*\)`)
-	}
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		if trimmedLine == "" {
-			continue
-		}
-		var libraryPath string
-		if os == "darwin" {
-			strippedLine := gLibraryNameRegex.ReplaceAllString(trimmedLine, "")
-			// omit the path of the executable that otool outputs, this is not a library
-			if strings.HasSuffix(strippedLine, ":") {
-				continue
-			}
-			libraryPath = strippedLine
-		} else {
-			libraryPath = trimmedLine
+	// we deliberately don't use `ldd` or `otool -L` but our own resolvers, as `ldd` may execute the
+	// loader of the analysed binary and `otool` is only available on macOS
+	var graph LibraryDependencyGraph
+	switch fileType {
+	case "ELF":
+		var ldLibraryPath []string
+		if !secureExecution {
+			ldLibraryPath = getLdLibraryPath(environment)
 		}
-		libraries = append(libraries, libraryPath)
+		graph, err = resolveElfDependencies(fsys, exePath, ldLibraryPath)
+	case "Mach-O", "Mach-O Universal":
+		graph, err = resolveMachODependencies(fsys, exePath)
+	default:
+		return nil, fmt.Errorf("unsupported binary format: %s", fileType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve libraries: %v", err)
+	}
+	for _, library := range graph.Unresolved {
+		fmt.Fprintf(os.Stderr, "WARNING: could not resolve library %s needed by %s\n", library.Name, library.NeededBy)
 	}
 
-	return libraries, nil
+	return graph.Libraries, nil
+}
+
+// returns the directories of LD_LIBRARY_PATH of an environment, which ld.so separates by colons or
+// semicolons
+func getLdLibraryPath(environment []string) []string {
+	var ldLibraryPath []string
+	for _, variable := range environment {
+		if value, found := strings.CutPrefix(variable, "LD_LIBRARY_PATH="); found {
+			ldLibraryPath = strings.FieldsFunc(value, func(r rune) bool { return r == ':' || r == ';' })
+		}
+	}
+	return ldLibraryPath
 }
 
-func getDynamicLibrarySize(libraryPath string) (int64, error) {
-	runningOs := runtime.GOOS
-	switch runningOs {
-	case "darwin":
-		// on darwin (macOS) as of macOS Catalina (10.15) core system libraries are no longer placed in /usr/lib/ and not regular files either
-		// to get their information, we use dyld_info which works boths for core system libraries and framework paths
-		// e.g. these commands work and provide their information:
-		// dyld_info /usr/lib/libSystem.B.dylib
-		// dyld_info /System/Library/Frameworks/IOKit.framework/Versions/A/IOKit
+// reports if ld.so runs an executable in secure-execution mode, see ld.so(8), in which it ignores
+// LD_LIBRARY_PATH so the environment of a user can't change the libraries of e.g. a setuid root binary
+func isSecureExecutable(fsys FileSystem, exePath string) bool {
+	fileInfo, err := fsys.Stat(exePath)
+	return err == nil && fileInfo.Mode()&(os.ModeSetuid|os.ModeSetgid) != 0
+}
 
+// returns the size of the executable code of a library and how it was measured, the binary format
+// (ELF or Mach-O) is detected from the file so binaries copied from another OS can be analysed as well
+func getDynamicLibrarySize(fsys FileSystem, libraryPath string) (int64, string, error) {
+	_, isHost := fsys.(hostFileSystem)
+	if isHost && runtime.GOOS == "darwin" {
 		// handle special library paths
 		switch libraryPath {
 		case "/System/DriverKit/usr/lib/libc++.dylib":
@@ -241,57 +458,29 @@ func getDynamicLibrarySize(libraryPath string) (int64, error) {
 		case "/System/DriverKit/System/Library/Frameworks/SerialDriverKit.framework/SerialDriverKit":
 			libraryPath = "/System/Library/Frameworks/SerialDriverKit.framework/SerialDriverKit"
 		case "/usr/appleinternal/lib/liblinkguard.dylib":
-			return 0, nil
+			return 0, "", nil
 		}
 
 		if strings.HasPrefix(libraryPath, "/AppleInternal/Library/Frameworks/") {
-			// these frameworks can't be queried with dyld_info
-			return 0, nil
-		}
-
-		if strings.HasPrefix(libraryPath, "@rpath/") {
-			// TODO: support looking up @rpath
-			return 0, nil
-		}
-
-		if strings.HasPrefix(libraryPath, "@executable_path/") {
-			// TODO: support looking up @executable_path
-			return 0, nil
+			// these frameworks are neither on disk nor in the dyld shared cache
+			return 0, "", nil
 		}
+	}
 
-		// get code/text segment size of library which contains the executable instructions
-		// note: we deliberately ignore the data and other sections as they are static artifacts
-		// and are not at risk of being penetrated
-		// TODO: parse dynamically linked libraries and recurse into them
-		shellCommand := fmt.Sprintf("dyld_info -segments '%s' | grep __TEXT | awk '{print $3}' | head -n 1", libraryPath)
-		var cmd *exec.Cmd
-		cmd = exec.Command("sh", "-c", shellCommand)
-		output, err := cmd.Output()
-		if err != nil {
-			return 0, fmt.Errorf("failed to retrieve library information for %s, error: %v", libraryPath, err)
-		}
-		if len(output) == 0 {
-			//fmt.Printf("WARNING: library: '%s' has no __TEXT section?!\n", libraryPath)
-			return 0, nil
-		}
-		bytes, err := bytefmt.ToBytes(string(output))
-		if err != nil {
-			return 0, fmt.Errorf("failed to convert string '%s' to bytes, error: %v", output, err)
-		}
-		return int64(bytes), nil
-	case "linux":
-		_, err2 := os.Readlink(libraryPath)
-		if err2 != nil {
-			return 0, fmt.Errorf("%s is a symbolic link", libraryPath)
-		} else {
-			// on Linux we can simply stat the library path as they are regular files
-			fileInfo, err := os.Stat(libraryPath)
-			if err != nil {
-				return 0, err
-			}
-			return fileInfo.Size(), nil
-		}
-	default:
-		return 0, fmt.Errorf("unsupported OS: %s", runningOs)
+	// get code/text segment size of library which contains the executable instructions
+	// note: we deliberately ignore the data and other sections as they are static artifacts
+	// and are not at risk of being penetrated
+	size, sizeMethod, err := getCodeSize(fsys, libraryPath)
+	if os.IsNotExist(err) && isHost && runtime.GOOS == "darwin" {
+		// on darwin (macOS) as of macOS Big Sur (11) core system libraries are no longer placed in /usr/lib/
+		// and /System/Library/Frameworks/ but only exist in the dyld shared cache, e.g.
+		// /usr/lib/libSystem.B.dylib
+		// /System/Library/Frameworks/IOKit.framework/Versions/A/IOKit
+		size, err = getDyldSharedCacheTextSize(libraryPath)
+		return size, sizeMethodDyldSharedCache, err
+	}
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to retrieve library information for %s, error: %v", libraryPath, err)
 	}
+	return size, sizeMethod, nil
 }
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetLdLibraryPath(t *testing.T) {
+	tests := []struct {
+		environment []string
+		expected    []string
+	}{
+		{nil, nil},
+		{[]string{"HOME=/root"}, nil},
+		{[]string{"LD_LIBRARY_PATH=/opt/lib"}, []string{"/opt/lib"}},
+		{[]string{"LD_LIBRARY_PATH=/opt/lib:/usr/local/lib"}, []string{"/opt/lib", "/usr/local/lib"}},
+		{[]string{"LD_LIBRARY_PATH=/opt/lib;/usr/local/lib"}, []string{"/opt/lib", "/usr/local/lib"}},
+		{[]string{"LD_LIBRARY_PATH=/opt/lib;/usr/local/lib:/srv/lib:"}, []string{"/opt/lib", "/usr/local/lib", "/srv/lib"}},
+	}
+	for _, test := range tests {
+		if ldLibraryPath := getLdLibraryPath(test.environment); !reflect.DeepEqual(ldLibraryPath, test.expected) {
+			t.Errorf("%v: got %v, expected %v", test.environment, ldLibraryPath, test.expected)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// memory-safety classes of a process
+const (
+	memorySafe    = "memory-safe"
+	memoryUnsafe  = "memory-unsafe"
+	memoryMixed   = "mixed" // memory-safe language with memory-unsafe code, e.g. Go with cgo
+	memoryUnknown = "unknown"
+)
+
+// MemorySafety classifies if the code of a process is written in memory-safe languages
+type MemorySafety struct {
+	Class   string   `json:"class"`   // memory-safe, memory-unsafe, mixed or unknown
+	Reasons []string `json:"reasons"` // why the process was classified this way
+}
+
+// classifies the memory-safety of a process by its detected language, the language evidence and
+// the libraries it loads, a memory-safe language that links C/C++ runtimes or loads C extensions
+// is only as safe as that code
+func classifyMemorySafety(info ProcessInfo) MemorySafety {
+	language := info.DetectedLanguage
+	switch language {
+	case "C", "C++", "Objective-C", "Fortran":
+		return MemorySafety{
+			Class:   memoryUnsafe,
+			Reasons: []string{language + " is not memory-safe"},
+		}
+	case "C++/CLI":
+		// mixed-mode assemblies contain native code compiled from C++ next to the managed code
+		return MemorySafety{
+			Class:   memoryMixed,
+			Reasons: []string{"C++/CLI mixes managed code with native C++ code"},
+		}
+	case "Go", "Rust", "Swift", "Java", "Python", "Node", "JavaScript/TypeScript", "Ruby", "Perl", ".NET", "C#", "VB.NET", "F#":
+	default:
+		return MemorySafety{
+			Class:   memoryUnknown,
+			Reasons: []string{"language could not be detected"},
+		}
+	}
+
+	var unsafeReasons []string
+	reported := map[string]bool{}
+	addReason := func(reason string) {
+		if !reported[reason] {
+			reported[reason] = true
+			unsafeReasons = append(unsafeReasons, reason)
+		}
+	}
+
+	// C/C++ and Fortran runtimes found by the language detector
+	evidenceReasons := map[string]string{
+		"C++ stdlib: ":      "links C++ runtime ",
+		"C++ runtime: ":     "links C++ runtime ",
+		"MSVC runtime: ":    "links MSVC runtime ",
+		"Qt framework: ":    "links Qt framework ",
+		"Fortran library: ": "links Fortran runtime ",
+	}
+	for _, evidence := range info.LanguageEvidence {
+		for prefix, reason := range evidenceReasons {
+			if library, found := strings.CutPrefix(evidence, prefix); found {
+				addReason(reason + library)
+			}
+		}
+	}
+
+	if info.GoBuildInfo != nil && info.GoBuildInfo.CgoEnabled {
+		// the C code of cgo is linked into the binary, also statically or against a libc we can't find
+		addReason("built with cgo (CGO_ENABLED=1)")
+	}
+
+	for _, library := range info.Libraries {
+		name := filepath.Base(library.Path)
+		switch {
+		case strings.HasPrefix(name, "libstdc++") || strings.HasPrefix(name, "libc++.") || strings.HasPrefix(name, "libc++abi"):
+			// also libraries linked by dependencies, e.g. a Rust crate wrapping a C++ library
+			addReason("links C++ runtime " + name)
+		case language == "Go" && info.GoBuildInfo == nil && (strings.HasPrefix(name, "libc.so") || strings.HasPrefix(name, "libpthread.so")):
+			// without build info, pure Go binaries are told apart by being statically linked on Linux
+			addReason(fmt.Sprintf("links %s, built with cgo", name))
+		case language == "Python" && library.Origin == "runtime" &&
+			(strings.Contains(name, ".cpython-") || strings.Contains(library.Path, "/lib-dynload/") || strings.Contains(library.Path, "-packages/")):
+			// C extensions of the standard library (lib-dynload) and of packages, e.g. numpy
+			addReason("loads C extension " + name)
+		case language == "JavaScript/TypeScript" && strings.HasSuffix(name, ".node"):
+			// native addons of packages, e.g. bcrypt
+			addReason("loads native addon " + name)
+		case language == "Ruby" && library.Origin == "runtime" && strings.Contains(library.Path, "/gems/"):
+			// C extensions of gems, e.g. nokogiri
+			addReason("loads C extension " + name)
+		case language == "Perl" && library.Origin == "runtime" && strings.Contains(library.Path, "/auto/"):
+			// XS modules, e.g. DBI
+			addReason("loads XS module " + name)
+		}
+	}
+
+	if info.PythonBundle != nil {
+		for _, extensionModule := range info.PythonBundle.ExtensionModules {
+			if strings.Contains(extensionModule, ".cpython-") || strings.HasSuffix(extensionModule, ".pyd") {
+				addReason("bundles C extension " + filepath.Base(extensionModule))
+			}
+		}
+	}
+
+	if len(unsafeReasons) > 0 {
+		return MemorySafety{
+			Class:   memoryMixed,
+			Reasons: append([]string{language + " is memory-safe"}, unsafeReasons...),
+		}
+	}
+	return MemorySafety{
+		Class:   memorySafe,
+		Reasons: []string{language + " is memory-safe"},
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestClassifyMemorySafetyOfGo(t *testing.T) {
+	libc := []LibraryInfo{{Path: "/lib/x86_64-linux-gnu/libc.so.6", Origin: "linked"}}
+	tests := []struct {
+		name        string
+		buildInfo   *GoBuildInfo
+		libraries   []LibraryInfo
+		wantClass   string
+		wantReasons int
+	}{
+		{"pure Go", &GoBuildInfo{}, nil, memorySafe, 1},
+		{"static cgo", &GoBuildInfo{CgoEnabled: true}, nil, memoryMixed, 2},
+		{"dynamic cgo", &GoBuildInfo{CgoEnabled: true}, libc, memoryMixed, 2},
+		{"no build info linking libc", nil, libc, memoryMixed, 2},
+		{"no build info", nil, nil, memorySafe, 1},
+	}
+	for _, test := range tests {
+		safety := classifyMemorySafety(ProcessInfo{DetectedLanguage: "Go", GoBuildInfo: test.buildInfo, Libraries: test.libraries})
+		if safety.Class != test.wantClass || len(safety.Reasons) != test.wantReasons {
+			t.Errorf("%s: got %s %v, want %s with %d reasons", test.name, safety.Class, safety.Reasons, test.wantClass, test.wantReasons)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// analyses all binaries of a directory tree without running them, e.g. an extracted root file
+// system of a golden image, libraries are resolved relative to the directory as if it was the root
+func scanRootDirectory(rootDirectory string, riskWeights RiskWeights) ([]ProcessInfo, error) {
+	fileInfo, err := os.Stat(rootDirectory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan root directory: %v", err)
+	}
+	if !fileInfo.IsDir() {
+		return nil, fmt.Errorf("failed to scan root directory: %s is not a directory", rootDirectory)
+	}
+
+	fsys := rootFileSystem{root: rootDirectory}
+	binaries := findBinaries(fsys, "/")
+	fmt.Fprintf(os.Stderr, "Analysing %d binaries in %s...\n", len(binaries), rootDirectory)
+
+	processInfos := []ProcessInfo{}
+	for _, binary := range binaries {
+		info := analyseBinary(fsys, binary, nil, "", nil)
+		info.Privilege = classifyPrivilege(info)
+		info.RiskScore = calculateRiskScore(info, riskWeights)
+		processInfos = append(processInfos, info)
+	}
+	return processInfos, nil
+}
+
+// analyses a binary that is not running, its entry has no pid and an unknown user id, runtimes and
+// interpreters are analysed by the application of their command line if it is known
+func analyseBinary(fsys FileSystem, path string, args []string, cwd string, environment []string) ProcessInfo {
+	info := ProcessInfo{
+		Name:           filepath.Base(path),
+		ExecutablePath: path,
+		UserId:         -1,
+	}
+	analyseExecutableSize(fsys, &info)
+
+	fmt.Fprintf(os.Stderr, "analysing executable: %s...\n", info.ExecutablePath)
+
+	interpreter, application := getProcessApplication(&info, args, cwd)
+	applicationCode := analyseProcessLanguage(fsys, &info, interpreter, application, args, cwd, environment)
+
+	libraries, err := getDynamicLibraries(fsys, path, environment, isSecureExecutable(fsys, path))
+	if err != nil {
+		// e.g. PE files, the binary itself is still attack-surface
+		fmt.Fprintf(os.Stderr, "WARNING: could not get libraries: %s\n", err)
+	}
+	for _, library := range libraries {
+		info.Libraries = append(info.Libraries, LibraryInfo{Path: library, Origin: "linked"})
+	}
+	info.Libraries = append(info.Libraries, applicationCode...)
+	analyseLibrarySizes(fsys, &info)
+	info.MemorySafety = classifyMemorySafety(info)
+
+	return info
+}
+
+// returns the paths of all ELF, PE and Mach-O files below a directory, symlinks are not followed
+// so each binary is only reported once
+func findBinaries(fsys FileSystem, directory string) []string {
+	entries, err := fsys.ReadDir(directory)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: could not read directory %s: %v\n", directory, err)
+		return nil
+	}
+
+	var binaries []string
+	for _, entry := range entries {
+		path := filepath.Join(directory, entry.Name())
+		switch {
+		case entry.IsDir():
+			if directory == "/" && (entry.Name() == "proc" || entry.Name() == "sys" || entry.Name() == "dev") {
+				// pseudo file systems of a mounted root contain no binaries
+				continue
+			}
+			binaries = append(binaries, findBinaries(fsys, path)...)
+		case entry.Type().IsRegular():
+			if isBinary(fsys, path) {
+				binaries = append(binaries, path)
+			}
+		}
+	}
+	return binaries
+}
+
+// reports if a file is an ELF, PE or Mach-O binary
+func isBinary(fsys FileSystem, path string) bool {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	fileType, _, err := detectFileType(file)
+	if err != nil {
+		return false
+	}
+	switch fileType {
+	case "ELF", "PE", "Mach-O", "Mach-O Universal":
+		return true
+	default:
+		return false
+	}
+}
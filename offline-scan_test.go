@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// copies a file of the language corpus into a root directory
+func copyTestFile(t *testing.T, root string, corpusFile string, path string) {
+	data, err := os.ReadFile(filepath.Join("testdata/language-corpus", corpusFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(root, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyseBinaryOfRuntime(t *testing.T) {
+	root := t.TempDir()
+	copyTestFile(t, root, "hello-c", "/usr/bin/dotnet")
+	copyTestFile(t, root, "hello-csharp.dll", "/app/hello.dll")
+	copyTestFile(t, root, "hello-c", "/usr/bin/python3")
+	copyTestFile(t, root, "hello-zipapp", "/app/hello.pyz")
+
+	tests := []struct {
+		name            string
+		executable      string
+		args            []string
+		cwd             string
+		wantLanguage    string
+		wantApplication string
+	}{
+		{"dotnet", "/usr/bin/dotnet", []string{"dotnet", "hello.dll"}, "/app", "C#", "/app/hello.dll"},
+		{"python", "/usr/bin/python3", []string{"python3", "-I", "hello.pyz"}, "/app", "Python", "/app/hello.pyz"},
+	}
+	for _, test := range tests {
+		info := analyseBinary(rootFileSystem{root: root}, test.executable, test.args, test.cwd, nil)
+		if info.DetectedLanguage != test.wantLanguage || info.ApplicationPath != test.wantApplication {
+			t.Errorf("%s: got %s of %q, want %s of %q", test.name, info.DetectedLanguage, info.ApplicationPath,
+				test.wantLanguage, test.wantApplication)
+		}
+		// every piece of evidence is weighted, including the application named on the command line
+		descriptions := []string{}
+		sources := map[string]bool{}
+		for _, evidence := range info.WeightedLanguageEvidence {
+			descriptions = append(descriptions, evidence.Description)
+			sources[evidence.Source] = true
+		}
+		if !reflect.DeepEqual(descriptions, info.LanguageEvidence) || !sources[evidenceCommandLine] {
+			t.Errorf("%s: got evidence %q and weighted evidence %+v", test.name, info.LanguageEvidence, info.WeightedLanguageEvidence)
+		}
+		// the executable of the runtime or interpreter is what gets exploited, so its mitigations are reported
+		if info.Hardening == nil || info.Hardening.Relro == "" {
+			t.Errorf("%s: got hardening %+v of the runtime", test.name, info.Hardening)
+		}
+	}
+}
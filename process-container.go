@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	gHostNamespaces map[string]uint64
+)
+
+// cgroup names of containers that include the runtime, e.g. docker-<id>.scope or libpod-<id>
+var containerScopePattern = regexp.MustCompile(`^(docker|cri-containerd|crio|libpod)-([0-9a-f]{64})(?:\.scope)?$`)
+var containerIdPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ContainerInfo attributes a process to its cgroup, container and namespaces (Linux only)
+type ContainerInfo struct {
+	CgroupPath               string `json:"cgroup_path"`
+	ContainerId              string `json:"container_id"`      // empty for processes not running in a container
+	ContainerRuntime         string `json:"container_runtime"` // docker, containerd, cri-o or podman
+	PidNamespace             uint64 `json:"pid_namespace"`     // inode of the namespace
+	MountNamespace           uint64 `json:"mnt_namespace"`
+	NetNamespace             uint64 `json:"net_namespace"`
+	SharesHostPidNamespace   bool   `json:"shares_host_pid_namespace"`
+	SharesHostMountNamespace bool   `json:"shares_host_mnt_namespace"`
+	SharesHostNetNamespace   bool   `json:"shares_host_net_namespace"`
+}
+
+func (c ContainerInfo) String() string {
+	if c.ContainerId == "" {
+		return "none"
+	}
+	var sharedNamespaces []string
+	if c.SharesHostPidNamespace {
+		sharedNamespaces = append(sharedNamespaces, "pid")
+	}
+	if c.SharesHostMountNamespace {
+		sharedNamespaces = append(sharedNamespaces, "mnt")
+	}
+	if c.SharesHostNetNamespace {
+		sharedNamespaces = append(sharedNamespaces, "net")
+	}
+	if len(sharedNamespaces) == 0 {
+		return fmt.Sprintf("%s %.12s", c.ContainerRuntime, c.ContainerId)
+	}
+	// e.g. a pod with hostNetwork: true is reachable like a process of the host
+	return fmt.Sprintf("%s %.12s (host %s)", c.ContainerRuntime, c.ContainerId, strings.Join(sharedNamespaces, ","))
+}
+
+// retrieves the cgroup, container and namespaces of a running process (Linux only)
+func getContainerInfo(pid int32) (ContainerInfo, error) {
+	info := ContainerInfo{}
+
+	file, err := os.Open(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return info, err
+	}
+	info.CgroupPath, err = parseProcCgroup(file)
+	file.Close()
+	if err != nil {
+		return info, fmt.Errorf("failed to parse /proc/%d/cgroup: %v", pid, err)
+	}
+	info.ContainerRuntime, info.ContainerId = parseContainerId(info.CgroupPath)
+
+	namespaces, err := getNamespaces(pid)
+	if err != nil {
+		return info, err
+	}
+	if gHostNamespaces == nil {
+		// initialize static cache instance, the namespaces of init are the ones of the host, without
+		// access to init (e.g. without CAP_SYS_PTRACE) we assume we run in the host namespaces
+		gHostNamespaces, err = getNamespaces(1)
+		if err != nil {
+			gHostNamespaces, err = getNamespaces(int32(os.Getpid()))
+		}
+		if err != nil {
+			gHostNamespaces = nil
+			return info, fmt.Errorf("failed to get namespaces of the host: %v", err)
+		}
+	}
+	info.PidNamespace = namespaces["pid"]
+	info.MountNamespace = namespaces["mnt"]
+	info.NetNamespace = namespaces["net"]
+	info.SharesHostPidNamespace = info.PidNamespace == gHostNamespaces["pid"]
+	info.SharesHostMountNamespace = info.MountNamespace == gHostNamespaces["mnt"]
+	info.SharesHostNetNamespace = info.NetNamespace == gHostNamespaces["net"]
+	return info, nil
+}
+
+// returns the pid, mnt and net namespace inodes of a process, /proc/<pid>/ns/* link to e.g. net:[4026531840]
+func getNamespaces(pid int32) (map[string]uint64, error) {
+	namespaces := map[string]uint64{}
+	for _, namespace := range []string{"pid", "mnt", "net"} {
+		target, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/%s", pid, namespace))
+		if err != nil {
+			return nil, err
+		}
+		value, found := strings.CutPrefix(target, namespace+":[")
+		if !found {
+			return nil, fmt.Errorf("invalid namespace: %s", target)
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(value, "]"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid namespace: %s", target)
+		}
+		namespaces[namespace] = inode
+	}
+	return namespaces, nil
+}
+
+// returns the cgroup path of the /proc/<pid>/cgroup format, the unified hierarchy (cgroup v2) is
+// preferred over the systemd and the first legacy (cgroup v1) hierarchy, e.g.
+// 0::/system.slice/docker-4f1c....scope
+// 12:pids:/docker/4f1c...
+func parseProcCgroup(reader io.Reader) (string, error) {
+	var unifiedPath, cgroupPath string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), ":", 3)
+		if len(fields) != 3 || !strings.HasPrefix(fields[2], "/") {
+			continue
+		}
+		switch {
+		case fields[0] == "0" && fields[1] == "":
+			unifiedPath = fields[2]
+		case fields[1] == "name=systemd" || cgroupPath == "":
+			cgroupPath = fields[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	// in hybrid mode (cgroup v1 and v2) runtimes often only use the legacy hierarchies and leave
+	// processes in the root of the unified one
+	if unifiedPath == "" || unifiedPath == "/" && cgroupPath != "" {
+		return cgroupPath, nil
+	}
+	return unifiedPath, nil
+}
+
+// parses the container runtime and id from a cgroup path, e.g.
+// /system.slice/docker-<id>.scope (docker with systemd cgroup driver)
+// /docker/<id> (docker with cgroupfs cgroup driver)
+// /kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod<uid>.slice/cri-containerd-<id>.scope
+// /kubepods/burstable/pod<uid>/<id> (containerd or cri-o with cgroupfs cgroup driver)
+// /machine.slice/libpod-<id>.scope/container (podman)
+func parseContainerId(cgroupPath string) (string, string) {
+	components := strings.Split(cgroupPath, "/")
+	// the container is the innermost cgroup with an id, runtimes may create sub cgroups inside of it
+	for i := len(components) - 1; i >= 0; i-- {
+		if match := containerScopePattern.FindStringSubmatch(components[i]); match != nil {
+			switch match[1] {
+			case "cri-containerd":
+				return "containerd", match[2]
+			case "crio":
+				return "cri-o", match[2]
+			case "libpod":
+				return "podman", match[2]
+			default:
+				return match[1], match[2]
+			}
+		}
+		if !containerIdPattern.MatchString(components[i]) {
+			continue
+		}
+		for _, parent := range components[:i] {
+			switch {
+			case parent == "docker":
+				return "docker", components[i]
+			case parent == "crio":
+				return "cri-o", components[i]
+			case strings.HasPrefix(parent, "libpod"):
+				return "podman", components[i]
+			}
+		}
+		// Kubernetes with the cgroupfs driver and plain containerd don't name the runtime
+		return "containerd", components[i]
+	}
+	return "", ""
+}
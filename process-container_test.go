@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testContainerId = "4f1c9a8e2b7d6c5f0a1e3b9d8c7f6e5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e"
+	testPodUid      = "0a1b2c3d_4e5f_6a7b_8c9d_0e1f2a3b4c5d"
+)
+
+func TestParseProcCgroup(t *testing.T) {
+	tests := []struct {
+		name     string
+		cgroup   string
+		expected string
+	}{
+		{"host with cgroup v2", "0::/\n", "/"},
+		{"service with cgroup v2", "0::/system.slice/sshd.service\n", "/system.slice/sshd.service"},
+		{"docker with cgroup v2", "0::/system.slice/docker-" + testContainerId + ".scope\n",
+			"/system.slice/docker-" + testContainerId + ".scope"},
+		{"docker with cgroup v1", "12:pids:/docker/" + testContainerId + "\n" +
+			"11:cpu,cpuacct:/docker/" + testContainerId + "\n" +
+			"1:name=systemd:/docker/" + testContainerId + "\n",
+			"/docker/" + testContainerId},
+		// the systemd hierarchy is preferred over the first legacy one
+		{"service with cgroup v1", "12:pids:/system.slice/nginx.service\n" +
+			"11:memory:/system.slice\n" +
+			"1:name=systemd:/system.slice/nginx.service\n",
+			"/system.slice/nginx.service"},
+		// in hybrid mode the unified hierarchy is only used if the process was moved into it
+		{"docker in hybrid mode", "12:pids:/docker/" + testContainerId + "\n" +
+			"1:name=systemd:/docker/" + testContainerId + "\n" +
+			"0::/\n",
+			"/docker/" + testContainerId},
+		{"service in hybrid mode", "12:pids:/system.slice/sshd.service\n" +
+			"1:name=systemd:/system.slice/sshd.service\n" +
+			"0::/system.slice/sshd.service\n",
+			"/system.slice/sshd.service"},
+		{"kubernetes with cgroup v1", "10:memory:/kubepods/burstable/pod" + testPodUid + "/" + testContainerId + "\n",
+			"/kubepods/burstable/pod" + testPodUid + "/" + testContainerId},
+		{"malformed lines", "garbage\n12:pids\n11:memory:docker\n\n0::/system.slice/cron.service\n", "/system.slice/cron.service"},
+		{"empty", "", ""},
+	}
+	for _, test := range tests {
+		path, err := parseProcCgroup(strings.NewReader(test.cgroup))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if path != test.expected {
+			t.Errorf("%s: got %q, expected %q", test.name, path, test.expected)
+		}
+	}
+}
+
+func TestParseContainerId(t *testing.T) {
+	tests := []struct {
+		path    string
+		runtime string
+		id      string
+	}{
+		{"/", "", ""},
+		{"/system.slice/sshd.service", "", ""},
+		{"/user.slice/user-1000.slice/session-2.scope", "", ""},
+		{"/docker/" + testContainerId, "docker", testContainerId},
+		{"/system.slice/docker-" + testContainerId + ".scope", "docker", testContainerId},
+		// sub cgroups created inside of the container
+		{"/system.slice/docker-" + testContainerId + ".scope/init.scope", "docker", testContainerId},
+		{"/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod" + testPodUid + ".slice/cri-containerd-" + testContainerId + ".scope",
+			"containerd", testContainerId},
+		{"/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + testPodUid + ".slice/cri-containerd-" + testContainerId + ".scope",
+			"containerd", testContainerId},
+		{"/kubepods/burstable/pod" + testPodUid + "/" + testContainerId, "containerd", testContainerId},
+		{"/kubepods/besteffort/pod" + testPodUid + "/" + testContainerId, "containerd", testContainerId},
+		{"/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod" + testPodUid + ".slice/crio-" + testContainerId + ".scope",
+			"cri-o", testContainerId},
+		{"/machine.slice/libpod-" + testContainerId + ".scope/container", "podman", testContainerId},
+		{"/user.slice/user-1000.slice/user@1000.service/user.slice/libpod-" + testContainerId + ".scope", "podman", testContainerId},
+		// the monitor of a container runs outside of it
+		{"/machine.slice/libpod-conmon-" + testContainerId + ".scope", "", ""},
+		{"/kubepods.slice/kubepods-burstable.slice/crio-conmon-" + testContainerId + ".scope", "", ""},
+		// ids are 64 hex digits
+		{"/docker/" + testContainerId[:12], "", ""},
+		{"/system.slice/docker-" + strings.ToUpper(testContainerId) + ".scope", "", ""},
+	}
+	for _, test := range tests {
+		runtime, id := parseContainerId(test.path)
+		if runtime != test.runtime || id != test.id {
+			t.Errorf("%s: got %q %q, expected %q %q", test.path, runtime, id, test.runtime, test.id)
+		}
+	}
+}
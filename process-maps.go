@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MemoryMapping describes a file-backed memory mapping of a process
+type MemoryMapping struct {
+	Path        string `json:"path"`          // path of the mapped file
+	Permissions string `json:"permissions"`   // e.g. r-xp, see proc(5)
+	Offset      int64  `json:"offset"`        // offset into the mapped file
+	SizeInBytes int64  `json:"size_in_bytes"` // size of the mapped memory region
+}
+
+// IsExecutable reports if the mapping contains code that can be executed
+func (m MemoryMapping) IsExecutable() bool {
+	return strings.Contains(m.Permissions, "x")
+}
+
+// retrieves the file-backed memory mappings of a running process (Linux only)
+func getMemoryMappings(pid int32) ([]MemoryMapping, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/maps", pid))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseMemoryMappings(file)
+}
+
+// parses the /proc/<pid>/maps format, e.g.
+// 7f2c1a400000-7f2c1a428000 r--p 00000000 08:01 1573012    /usr/lib/x86_64-linux-gnu/libc.so.6
+func parseMemoryMappings(reader io.Reader) ([]MemoryMapping, error) {
+	var mappings []MemoryMapping
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		// the path is the 6th field and may contain spaces
+		fields := strings.SplitN(scanner.Text(), " ", 6)
+		if len(fields) < 6 {
+			// anonymous mapping
+			continue
+		}
+		path := strings.TrimSpace(fields[5])
+		inode := fields[4]
+		if inode == "0" || !strings.HasPrefix(path, "/") {
+			// anonymous mappings or pseudo paths like [heap], [stack] or [vdso]
+			continue
+		}
+		// the file was replaced or removed on disk after it was mapped, e.g. by a package update
+		path = strings.TrimSuffix(path, " (deleted)")
+
+		start, end, found := strings.Cut(fields[0], "-")
+		if !found {
+			return nil, fmt.Errorf("invalid address range: %s", fields[0])
+		}
+		startAddress, err := strconv.ParseUint(start, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start address: %s", start)
+		}
+		endAddress, err := strconv.ParseUint(end, 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end address: %s", end)
+		}
+		offset, err := strconv.ParseInt(fields[2], 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid offset: %s", fields[2])
+		}
+
+		mappings = append(mappings, MemoryMapping{
+			Path:        path,
+			Permissions: fields[1],
+			Offset:      offset,
+			SizeInBytes: int64(endAddress - startAddress),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return mappings, nil
+}
+
+// returns the unique paths of all files that are mapped as executable code,
+// this covers dynamically linked libraries as well as dlopen()ed plugins, NSS modules etc.
+func getExecutableMappedFiles(mappings []MemoryMapping) []string {
+	var paths []string
+	seen := map[string]bool{}
+	for _, mapping := range mappings {
+		if !mapping.IsExecutable() || seen[mapping.Path] {
+			continue
+		}
+		seen[mapping.Path] = true
+		paths = append(paths, mapping.Path)
+	}
+	return paths
+}
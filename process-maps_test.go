@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMemoryMappings(t *testing.T) {
+	file, err := os.Open("testdata/procfs/maps")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	mappings, err := parseMemoryMappings(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// anonymous mappings and pseudo paths like [heap], [vdso] or [vsyscall] are no files
+	if len(mappings) != 19 {
+		t.Errorf("got %d mappings, expected 19", len(mappings))
+	}
+	for _, mapping := range mappings {
+		if !strings.HasPrefix(mapping.Path, "/") || strings.HasSuffix(mapping.Path, " (deleted)") {
+			t.Errorf("got mapping of %q", mapping.Path)
+		}
+	}
+	expected := []MemoryMapping{
+		{Path: "/usr/bin/cat", Permissions: "r-xp", Offset: 0x2000, SizeInBytes: 0x5000},
+		{Path: "/opt/My App/plugins/libgreeter.so", Permissions: "r-xp", Offset: 0x1000, SizeInBytes: 0x1000},
+		{Path: "/usr/lib/x86_64-linux-gnu/libssl.so.3", Permissions: "r-xp", Offset: 0x6a000, SizeInBytes: 0x9a000},
+	}
+	for _, mapping := range expected {
+		found := false
+		for _, got := range mappings {
+			found = found || got == mapping
+		}
+		if !found {
+			t.Errorf("missing mapping %+v", mapping)
+		}
+	}
+
+	// the executable comes first, the vdso is no file
+	paths := getExecutableMappedFiles(mappings)
+	expectedPaths := []string{
+		"/usr/bin/cat",
+		"/opt/My App/plugins/libgreeter.so",
+		"/usr/lib/x86_64-linux-gnu/libssl.so.3",
+		"/usr/lib/x86_64-linux-gnu/libc.so.6",
+		"/usr/lib/x86_64-linux-gnu/ld-linux-x86-64.so.2",
+	}
+	if !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("got executable files %q, expected %q", paths, expectedPaths)
+	}
+}
+
+func TestParseMemoryMappingsBroken(t *testing.T) {
+	for _, line := range []string{
+		"7fad50e9a000 r-xp 00026000 fe:00 700582 /usr/lib/x86_64-linux-gnu/libc.so.6",
+		"7fad50e9a000-7fad50ff0zzz r-xp 00026000 fe:00 700582 /usr/lib/x86_64-linux-gnu/libc.so.6",
+		"7fad50e9a000-7fad50ff0000 r-xp 0002600g fe:00 700582 /usr/lib/x86_64-linux-gnu/libc.so.6",
+	} {
+		if mappings, err := parseMemoryMappings(strings.NewReader(line)); err == nil {
+			t.Errorf("%s: got %+v", line, mappings)
+		}
+	}
+}
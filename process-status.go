@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ProcessStatus holds the credentials and security attributes of a running process (Linux only)
+type ProcessStatus struct {
+	Uids                  [4]int `json:"uids"` // real, effective, saved set and file system user id
+	Gids                  [4]int `json:"gids"` // real, effective, saved set and file system group id
+	EffectiveCapabilities uint64 `json:"effective_capabilities"`
+	PermittedCapabilities uint64 `json:"permitted_capabilities"`
+	BoundingCapabilities  uint64 `json:"bounding_capabilities"`
+	Seccomp               string `json:"seccomp"` // disabled, strict or filter
+	NoNewPrivs            bool   `json:"no_new_privs"`
+}
+
+// capability names by bit number, see capabilities(7)
+var capabilityNames = []string{
+	"cap_chown", "cap_dac_override", "cap_dac_read_search", "cap_fowner", "cap_fsetid", "cap_kill",
+	"cap_setgid", "cap_setuid", "cap_setpcap", "cap_linux_immutable", "cap_net_bind_service",
+	"cap_net_broadcast", "cap_net_admin", "cap_net_raw", "cap_ipc_lock", "cap_ipc_owner", "cap_sys_module",
+	"cap_sys_rawio", "cap_sys_chroot", "cap_sys_ptrace", "cap_sys_pacct", "cap_sys_admin", "cap_sys_boot",
+	"cap_sys_nice", "cap_sys_resource", "cap_sys_time", "cap_sys_tty_config", "cap_mknod", "cap_lease",
+	"cap_audit_write", "cap_audit_control", "cap_setfcap", "cap_mac_override", "cap_mac_admin",
+	"cap_syslog", "cap_wake_alarm", "cap_block_suspend", "cap_audit_read", "cap_perfmon", "cap_bpf",
+	"cap_checkpoint_restore",
+}
+
+const capSysAdmin = 21
+
+// AT_SECURE entry of the auxiliary vector, see getauxval(3)
+const auxiliaryVectorSecure = 23
+
+// capabilities that are equivalent to root, as they allow to take over the system or to bypass
+// file permissions
+const adminCapabilities uint64 = 1<<0 | 1<<1 | 1<<2 | 1<<3 | 1<<6 | 1<<7 | 1<<8 | 1<<12 | 1<<16 |
+	1<<17 | 1<<19 | 1<<21 | 1<<22 | 1<<31 | 1<<32 | 1<<33 | 1<<39
+
+// returns the names of the capabilities of a capability set, e.g. cap_net_bind_service
+func getCapabilityNames(capabilities uint64) []string {
+	var names []string
+	for bit := 0; bit < 64; bit++ {
+		if capabilities&(1<<bit) == 0 {
+			continue
+		}
+		if bit < len(capabilityNames) {
+			names = append(names, capabilityNames[bit])
+		} else {
+			names = append(names, fmt.Sprintf("cap_%d", bit))
+		}
+	}
+	return names
+}
+
+// retrieves the credentials and security attributes of a running process from /proc/<pid>/status (Linux only)
+func getProcessStatus(pid int32) (*ProcessStatus, error) {
+	file, err := os.Open(fmt.Sprintf("/proc/%d/status", pid))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	status, err := parseProcessStatus(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse /proc/%d/status: %v", pid, err)
+	}
+	return status, nil
+}
+
+// parses the fields of the /proc/<pid>/status format we are interested in, e.g.
+// Uid:	1000	1000	1000	1000
+// CapEff:	0000000000000400
+// NoNewPrivs:	0
+// Seccomp:	2
+func parseProcessStatus(reader io.Reader) (*ProcessStatus, error) {
+	status := &ProcessStatus{Seccomp: "disabled"}
+	foundIds := 0
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		key, value, found := strings.Cut(scanner.Text(), ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		var err error
+		switch key {
+		case "Uid", "Gid":
+			ids := &status.Uids
+			if key == "Gid" {
+				ids = &status.Gids
+			}
+			fields := strings.Fields(value)
+			if len(fields) != len(ids) {
+				return nil, fmt.Errorf("invalid %s: %s", key, value)
+			}
+			for i, field := range fields {
+				if ids[i], err = strconv.Atoi(field); err != nil {
+					return nil, fmt.Errorf("invalid %s: %s", key, value)
+				}
+			}
+			foundIds++
+		case "CapEff":
+			status.EffectiveCapabilities, err = strconv.ParseUint(value, 16, 64)
+		case "CapPrm":
+			status.PermittedCapabilities, err = strconv.ParseUint(value, 16, 64)
+		case "CapBnd":
+			status.BoundingCapabilities, err = strconv.ParseUint(value, 16, 64)
+		case "NoNewPrivs":
+			status.NoNewPrivs = value == "1"
+		case "Seccomp":
+			// Seccomp is only present if the kernel was built with CONFIG_SECCOMP
+			switch value {
+			case "1":
+				status.Seccomp = "strict"
+			case "2":
+				status.Seccomp = "filter"
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s", key, value)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if foundIds != 2 {
+		return nil, fmt.Errorf("no Uid or Gid found")
+	}
+	return status, nil
+}
+
+// reports if the dynamic loader runs a process in secure-execution mode, see ld.so(8), as it was
+// started from a setuid or setgid executable or one with file capabilities (Linux only)
+func getProcessSecureExecution(pid int32) (bool, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/auxv", pid))
+	if err != nil {
+		return false, err
+	}
+	vector, err := parseAuxiliaryVector(data)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse /proc/%d/auxv: %v", pid, err)
+	}
+	return vector[auxiliaryVectorSecure] != 0, nil
+}
+
+// parses the auxiliary vector of /proc/<pid>/auxv, its entries are pairs of type and value in the
+// word size of the process, which is 32 bit for i386 processes on x86_64 hosts
+func parseAuxiliaryVector(data []byte) (map[uint64]uint64, error) {
+	for _, wordSize := range []int{8, 4} {
+		vector := map[uint64]uint64{}
+		for offset := 0; offset+2*wordSize <= len(data); offset += 2 * wordSize {
+			var key, value uint64
+			if wordSize == 8 {
+				key = binary.NativeEndian.Uint64(data[offset:])
+				value = binary.NativeEndian.Uint64(data[offset+8:])
+			} else {
+				key = uint64(binary.NativeEndian.Uint32(data[offset:]))
+				value = uint64(binary.NativeEndian.Uint32(data[offset+4:]))
+			}
+			if key == 0 {
+				// AT_NULL ends the vector
+				return vector, nil
+			}
+			if key > 64 {
+				// types are small numbers, this is the wrong word size
+				break
+			}
+			vector[key] = value
+		}
+	}
+	return nil, fmt.Errorf("no AT_NULL found")
+}
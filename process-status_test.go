@@ -0,0 +1,33 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestParseAuxiliaryVector(t *testing.T) {
+	// AT_PAGESZ, AT_UID, AT_SECURE and AT_NULL
+	entries := [][2]uint64{{6, 4096}, {11, 1000}, {auxiliaryVectorSecure, 1}, {0, 0}}
+	for _, wordSize := range []int{8, 4} {
+		var data []byte
+		for _, entry := range entries {
+			for _, word := range entry {
+				if wordSize == 8 {
+					data = binary.NativeEndian.AppendUint64(data, word)
+				} else {
+					data = binary.NativeEndian.AppendUint32(data, uint32(word))
+				}
+			}
+		}
+		vector, err := parseAuxiliaryVector(data)
+		if err != nil {
+			t.Fatalf("%d bit: %v", wordSize*8, err)
+		}
+		if vector[auxiliaryVectorSecure] != 1 || vector[6] != 4096 || len(vector) != 3 {
+			t.Errorf("%d bit: got %v", wordSize*8, vector)
+		}
+	}
+	if _, err := parseAuxiliaryVector([]byte{1, 2, 3}); err == nil {
+		t.Errorf("no error for a truncated vector")
+	}
+}
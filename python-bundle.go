@@ -0,0 +1,444 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// PythonBundle describes a Python application frozen into an executable, e.g. with PyInstaller
+type PythonBundle struct {
+	Packager         string   `json:"packager"`          // PyInstaller, Nuitka, zip or embedded
+	PythonVersion    string   `json:"python_version"`    // e.g. 3.11, empty if unknown
+	PythonLibrary    string   `json:"python_library"`    // e.g. libpython3.11.so.1.0, empty if unknown
+	Scripts          []string `json:"scripts"`           // entry point and runtime hooks
+	Modules          []string `json:"modules"`           // bundled Python modules
+	ExtensionModules []string `json:"extension_modules"` // bundled native libraries, e.g. C extensions
+}
+
+// PyInstaller appends a CArchive to its bootloader, which ends with a cookie
+// https://pyinstaller.org/en/stable/advanced-topics.html#carchive
+var pyInstallerCookieMagic = []byte("MEI\014\013\012\013\016")
+
+const pyInstallerCookieSize = 24 + 64 // PyInstaller 2.1+ appends the name of the Python library
+const pyInstallerLegacyCookieSize = 24
+
+// the cookie is at the end of the archive, which is only followed by a code signature
+const pyInstallerCookieSearchSize = 1024 * 1024
+
+var nuitkaPattern = regexp.MustCompile(`NUITKA_ONEFILE_PARENT|NUITKA_ONEFILE_BINARY|__nuitka_binary_dir|\bcompiled_function\b`)
+var embeddedPythonZipPattern = regexp.MustCompile(`python(\d)(\d+)\.zip`)
+var pythonLibraryVersionPattern = regexp.MustCompile(`python(\d)\.?(\d+)`)
+
+// detects Python applications frozen into an executable: PyInstaller archives, Nuitka compiled
+// code, zip archives of Python modules appended to the executable (zipapp) and interpreters
+// embedding the standard library as python3X.zip, returns nil for other executables
+func getPythonBundle(file File, fileType string) *PythonBundle {
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return nil
+	}
+	readOnlyData := getReadOnlyData(file, fileType)
+
+	if bundle, err := getPyInstallerBundle(file, fileInfo.Size(), fileType); err == nil {
+		return bundle
+	}
+	if nuitkaPattern.Match(readOnlyData) {
+		// compiled modules are not listed anywhere
+		bundle := newPythonBundle("Nuitka")
+		if match := pythonLibraryVersionPattern.FindSubmatch(readOnlyData); match != nil {
+			bundle.PythonVersion = string(match[1]) + "." + string(match[2])
+		}
+		return bundle
+	}
+	if bundle := getPythonZipBundle(file, fileInfo.Size()); bundle != nil {
+		return bundle
+	}
+	if match := embeddedPythonZipPattern.FindSubmatch(readOnlyData); match != nil {
+		bundle := newPythonBundle("embedded")
+		bundle.PythonVersion = string(match[1]) + "." + string(match[2])
+		bundle.PythonLibrary = string(match[0])
+		return bundle
+	}
+	return nil
+}
+
+func newPythonBundle(packager string) *PythonBundle {
+	return &PythonBundle{
+		Packager:         packager,
+		Scripts:          []string{},
+		Modules:          []string{},
+		ExtensionModules: []string{},
+	}
+}
+
+// records a frozen Python application with the evidence it gives for Python
+func addPythonBundle(info BinaryLanguageInfo, bundle *PythonBundle) BinaryLanguageInfo {
+	if bundle == nil {
+		return info
+	}
+	info.PythonBundle = bundle
+	switch bundle.Packager {
+	case "embedded":
+		// also C/C++ applications with Python scripting, e.g. Blender
+		info.addEvidence(evidenceString, "Embedded Python standard library: "+bundle.PythonLibrary, "Python")
+	default:
+		description := bundle.Packager + " bundle: "
+		if bundle.PythonVersion != "" {
+			// zip archives don't tell the Python version
+			description += "Python " + bundle.PythonVersion + ", "
+		}
+		info.addEvidence(evidenceStructural, description+fmt.Sprintf("%d modules", len(bundle.Modules)), "Python")
+	}
+	return info
+}
+
+// returns the read-only data of a binary, where compilers put string literals
+func getReadOnlyData(file File, fileType string) []byte {
+	var data []byte
+	switch fileType {
+	case "ELF":
+		if f, err := elf.NewFile(file); err == nil {
+			data = getElfSectionData(f, ".rodata")
+		}
+	case "PE":
+		if f, err := pe.NewFile(file); err == nil {
+			if section := f.Section(".rdata"); section != nil {
+				data, _ = section.Data()
+			}
+		}
+	case "Mach-O":
+		if f, err := macho.NewFile(file); err == nil {
+			if section := f.Section("__cstring"); section != nil {
+				data, _ = section.Data()
+			}
+		}
+	}
+	return data
+}
+
+// parses the table of contents of a PyInstaller CArchive and of its PYZ module archive
+func getPyInstallerBundle(file File, fileSize int64, fileType string) (*PythonBundle, error) {
+	// PyInstaller 5+ stores the archive in the pydata section of ELF binaries, followed by other sections
+	searchEnd := fileSize
+	if fileType == "ELF" {
+		if f, err := elf.NewFile(file); err == nil {
+			if section := f.Section("pydata"); section != nil {
+				searchEnd = int64(section.Offset + section.Size)
+			}
+		}
+	}
+	searchStart := max(0, searchEnd-pyInstallerCookieSearchSize)
+	data := make([]byte, searchEnd-searchStart)
+	if _, err := file.ReadAt(data, searchStart); err != nil {
+		return nil, err
+	}
+	index := bytes.LastIndex(data, pyInstallerCookieMagic)
+	if index < 0 {
+		return nil, fmt.Errorf("no PyInstaller cookie found")
+	}
+	cookie := data[index:]
+	if len(cookie) < pyInstallerLegacyCookieSize {
+		return nil, fmt.Errorf("truncated PyInstaller cookie")
+	}
+
+	// magic, length of the archive, offset and length of the table of contents and Python version
+	packageLength := int64(binary.BigEndian.Uint32(cookie[8:12]))
+	tocOffset := int64(binary.BigEndian.Uint32(cookie[12:16]))
+	tocLength := int64(binary.BigEndian.Uint32(cookie[16:20]))
+	pythonVersion := int(binary.BigEndian.Uint32(cookie[20:24]))
+
+	bundle := newPythonBundle("PyInstaller")
+	if pythonVersion >= 100 {
+		bundle.PythonVersion = fmt.Sprintf("%d.%d", pythonVersion/100, pythonVersion%100)
+	} else {
+		bundle.PythonVersion = fmt.Sprintf("%d.%d", pythonVersion/10, pythonVersion%10)
+	}
+	cookieSize := int64(pyInstallerLegacyCookieSize)
+	if len(cookie) >= pyInstallerCookieSize && bytes.Contains(bytes.ToLower(cookie[24:pyInstallerCookieSize]), []byte("python")) {
+		cookieSize = pyInstallerCookieSize
+		bundle.PythonLibrary = string(bytes.TrimRight(cookie[24:pyInstallerCookieSize], "\x00"))
+	}
+
+	packageStart := searchStart + int64(index) + cookieSize - packageLength
+	if packageStart < 0 || tocLength > packageLength || packageStart+tocOffset+tocLength > fileSize {
+		return nil, fmt.Errorf("invalid PyInstaller cookie")
+	}
+	toc := make([]byte, tocLength)
+	if _, err := file.ReadAt(toc, packageStart+tocOffset); err != nil {
+		return nil, fmt.Errorf("failed to read PyInstaller table of contents: %v", err)
+	}
+
+	// entries: length of the entry, offset, compressed and uncompressed length of the data,
+	// compression flag, type code and the null padded name
+	for len(toc) >= 18 {
+		entryLength := int(binary.BigEndian.Uint32(toc[0:4]))
+		if entryLength < 18 || entryLength > len(toc) {
+			break
+		}
+		dataOffset := int64(binary.BigEndian.Uint32(toc[4:8]))
+		dataLength := int64(binary.BigEndian.Uint32(toc[8:12]))
+		typeCode := toc[17]
+		name := string(bytes.TrimRight(toc[18:entryLength], "\x00"))
+		toc = toc[entryLength:]
+
+		switch typeCode {
+		case 's':
+			bundle.Scripts = append(bundle.Scripts, name)
+		case 'm', 'M':
+			bundle.Modules = append(bundle.Modules, name)
+		case 'b':
+			bundle.ExtensionModules = append(bundle.ExtensionModules, name)
+		case 'z', 'Z':
+			// most modules are in the PYZ archive
+			modules, err := getPyzModules(io.NewSectionReader(file, packageStart+dataOffset, dataLength))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "WARNING: %v\n", err)
+			}
+			bundle.Modules = append(bundle.Modules, modules...)
+		}
+	}
+	return bundle, nil
+}
+
+// returns the names of the modules in a PYZ archive, its table of contents is a marshalled list of
+// (name, (type code, offset, length)) or a dict for older PyInstaller versions
+func getPyzModules(reader *io.SectionReader) ([]string, error) {
+	// magic, Python bytecode magic and offset of the table of contents
+	header := make([]byte, 12)
+	if _, err := reader.ReadAt(header, 0); err != nil {
+		return nil, fmt.Errorf("failed to read PYZ archive: %v", err)
+	}
+	if !bytes.Equal(header[0:4], []byte("PYZ\x00")) {
+		return nil, fmt.Errorf("invalid PYZ archive")
+	}
+	tocOffset := int64(binary.BigEndian.Uint32(header[8:12]))
+	if tocOffset > reader.Size() {
+		return nil, fmt.Errorf("invalid PYZ archive")
+	}
+	toc := make([]byte, reader.Size()-tocOffset)
+	if _, err := reader.ReadAt(toc, tocOffset); err != nil {
+		return nil, fmt.Errorf("failed to read PYZ table of contents: %v", err)
+	}
+
+	unmarshaller := marshalReader{data: toc}
+	value, err := unmarshaller.read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse PYZ table of contents: %v", err)
+	}
+	entries, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse PYZ table of contents: unexpected type %T", value)
+	}
+	modules := []string{}
+	for _, entry := range entries {
+		if pair, ok := entry.([]interface{}); ok && len(pair) > 0 {
+			if name, ok := pair[0].(string); ok {
+				modules = append(modules, name)
+			}
+		}
+	}
+	return modules, nil
+}
+
+// minimal reader of the Python marshal format, enough for tables of contents: lists, tuples and
+// dicts (as list of key/value tuples) of strings and numbers
+// https://github.com/python/cpython/blob/main/Python/marshal.c
+type marshalReader struct {
+	data []byte
+	refs []interface{}
+}
+
+const marshalFlagRef = 0x80
+
+func (r *marshalReader) read() (interface{}, error) {
+	typeCode, err := r.readBytes(1)
+	if err != nil {
+		return nil, err
+	}
+	isRef := typeCode[0]&marshalFlagRef != 0
+	refIndex := len(r.refs)
+	if isRef {
+		// containers are referenced before their elements are read
+		r.refs = append(r.refs, nil)
+	}
+
+	var value interface{}
+	switch typeCode[0] &^ marshalFlagRef {
+	case 'N', '0':
+		value = nil
+	case 'T':
+		value = true
+	case 'F':
+		value = false
+	case 'i':
+		n, err := r.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		value = int64(n)
+	case 'l':
+		// arbitrary-precision int as 15 bit digits, offsets don't need more than 64 bits
+		digits, err := r.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		count := digits
+		if count < 0 {
+			count = -count
+		}
+		var n int64
+		for i := 0; i < int(count); i++ {
+			digit, err := r.readBytes(2)
+			if err != nil {
+				return nil, err
+			}
+			n |= int64(binary.LittleEndian.Uint16(digit)) << (15 * i)
+		}
+		if digits < 0 {
+			n = -n
+		}
+		value = n
+	case 'g':
+		_, err = r.readBytes(8)
+	case 's', 't', 'a', 'A', 'u':
+		length, err := r.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		s, err := r.readBytes(int(length))
+		if err != nil {
+			return nil, err
+		}
+		value = string(s)
+	case 'z', 'Z':
+		length, err := r.readBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		s, err := r.readBytes(int(length[0]))
+		if err != nil {
+			return nil, err
+		}
+		value = string(s)
+	case '(', '[', '<', '>', ')':
+		var count int
+		if typeCode[0]&^marshalFlagRef == ')' {
+			n, err := r.readBytes(1)
+			if err != nil {
+				return nil, err
+			}
+			count = int(n[0])
+		} else {
+			n, err := r.readInt32()
+			if err != nil {
+				return nil, err
+			}
+			count = int(n)
+		}
+		if count < 0 || count > len(r.data) {
+			return nil, fmt.Errorf("invalid marshal container size %d", count)
+		}
+		elements := make([]interface{}, 0, count)
+		for i := 0; i < count; i++ {
+			element, err := r.read()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		value = elements
+	case '{':
+		var pairs []interface{}
+		for len(r.data) > 0 && r.data[0] != '0' {
+			key, err := r.read()
+			if err != nil {
+				return nil, err
+			}
+			element, err := r.read()
+			if err != nil {
+				return nil, err
+			}
+			pairs = append(pairs, []interface{}{key, element})
+		}
+		if _, err := r.readBytes(1); err != nil {
+			return nil, err
+		}
+		value = pairs
+	case 'r':
+		index, err := r.readInt32()
+		if err != nil {
+			return nil, err
+		}
+		if index < 0 || int(index) >= len(r.refs) {
+			return nil, fmt.Errorf("invalid marshal reference %d", index)
+		}
+		value = r.refs[index]
+	default:
+		return nil, fmt.Errorf("unsupported marshal type %q", typeCode[0]&^marshalFlagRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if isRef {
+		r.refs[refIndex] = value
+	}
+	return value, nil
+}
+
+func (r *marshalReader) readBytes(n int) ([]byte, error) {
+	if n < 0 || n > len(r.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b, nil
+}
+
+func (r *marshalReader) readInt32() (int32, error) {
+	b, err := r.readBytes(4)
+	if err != nil {
+		return 0, err
+	}
+	return int32(binary.LittleEndian.Uint32(b)), nil
+}
+
+// detects a zip archive of Python modules appended to an executable, e.g. by zipapp or py2exe
+func getPythonZipBundle(file File, fileSize int64) *PythonBundle {
+	archive, err := zip.NewReader(file, fileSize)
+	if err != nil {
+		return nil
+	}
+	bundle := newPythonBundle("zip")
+	for _, zipFile := range archive.File {
+		name := zipFile.Name
+		switch {
+		case name == "__main__.py" || name == "__main__.pyc":
+			bundle.Scripts = append(bundle.Scripts, name)
+		case strings.HasSuffix(name, ".py") || strings.HasSuffix(name, ".pyc"):
+			module := strings.TrimSuffix(strings.TrimSuffix(name, ".pyc"), ".py")
+			bundle.Modules = append(bundle.Modules, strings.ReplaceAll(strings.TrimSuffix(module, "/__init__"), "/", "."))
+		case strings.HasSuffix(name, ".so") || strings.HasSuffix(name, ".pyd"):
+			bundle.ExtensionModules = append(bundle.ExtensionModules, name)
+		case embeddedPythonZipPattern.MatchString(name):
+			// standard library of an embedded interpreter
+			match := embeddedPythonZipPattern.FindStringSubmatch(name)
+			bundle.PythonVersion = match[1] + "." + match[2]
+			bundle.PythonLibrary = name
+		}
+	}
+	if len(bundle.Scripts) == 0 && len(bundle.Modules) == 0 && bundle.PythonLibrary == "" {
+		// e.g. a self-extracting archive of something else
+		return nil
+	}
+	return bundle
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestGetPythonZipBundle(t *testing.T) {
+	file, err := os.Open("testdata/language-corpus/hello-zipapp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	fileInfo, err := file.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bundle := getPythonZipBundle(file, fileInfo.Size())
+	want := &PythonBundle{
+		Packager:         "zip",
+		Scripts:          []string{"__main__.py"},
+		Modules:          []string{"hello_helper"},
+		ExtensionModules: []string{},
+	}
+	if !reflect.DeepEqual(bundle, want) {
+		t.Fatalf("got %+v, want %+v", bundle, want)
+	}
+
+	info := addPythonBundle(BinaryLanguageInfo{}, bundle)
+	if len(info.Evidence) != 1 || info.Evidence[0] != "zip bundle: 1 modules" {
+		t.Errorf("got evidence %q", info.Evidence)
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/bytefmt" // to convert bytes into/from human-readable format
+	. "github.com/ahmetb/go-linq"   // LINQ for Go to manage data structure like its 2025
+)
+
+// version of the JSON report schema, increase it on incompatible changes like removed or renamed fields
+const reportSchemaVersion = 2
+
+// Report is the JSON representation of the report
+type Report struct {
+	SchemaVersion int           `json:"schema_version"`
+	GeneratedAt   time.Time     `json:"generated_at"`
+	Processes     []ProcessInfo `json:"processes"`
+	Summary       HostSummary   `json:"summary"`
+}
+
+// writes the report with one line per process, optionally followed by a tree of its libraries, and
+// the summary of the whole host
+func printTextReport(writer io.Writer, processInfos []ProcessInfo, details bool) {
+	for _, info := range processInfos {
+		var displayedName string
+		if info.Name == "" {
+			displayedName = "N/A"
+		} else {
+			displayedName = info.Name
+		}
+
+		var displayedLanguage string
+		if info.DetectedLanguage == "" {
+			displayedLanguage = "N/A"
+		} else {
+			displayedLanguage = info.DetectedLanguage
+		}
+		// outdated toolchains are a known vulnerability
+		if info.GoBuildInfo != nil && info.DetectedLanguage == "Go" {
+			displayedLanguage += " " + info.GoBuildInfo.GoVersion
+		}
+		if info.RustBuildInfo != nil && info.RustBuildInfo.RustcVersion != "" && info.DetectedLanguage == "Rust" {
+			displayedLanguage += " " + strings.Fields(info.RustBuildInfo.RustcVersion)[0]
+		}
+		if info.PythonBundle != nil {
+			displayedLanguage = strings.TrimSpace(displayedLanguage+" "+info.PythonBundle.PythonVersion) + " (" + info.PythonBundle.Packager + ")"
+		}
+		if info.JavaScriptBundle != nil {
+			displayedLanguage += " (" + strings.TrimSpace(info.JavaScriptBundle.Packager+" "+info.JavaScriptBundle.RuntimeVersion) + ")"
+		}
+		if info.DotNetAssembly != nil && info.DotNetAssembly.TargetFramework != "" {
+			displayedLanguage += " (" + getTargetFrameworkMoniker(info.DotNetAssembly.TargetFramework) + ")"
+		}
+
+		displayedHardening := "N/A"
+		if info.Hardening != nil {
+			displayedHardening = info.Hardening.String()
+		}
+
+		var listeningSockets []string
+		for _, socket := range info.ListeningSockets {
+			listeningSockets = append(listeningSockets, socket.String())
+		}
+		for _, socket := range info.UnixSockets {
+			listeningSockets = append(listeningSockets, socket.String())
+		}
+		displayedListeningSockets := "none"
+		if len(listeningSockets) > 0 {
+			displayedListeningSockets = strings.Join(listeningSockets, ", ")
+		}
+
+		linkedCount := From(info.Libraries).CountWithT(func(l LibraryInfo) bool { return l.Origin == "linked" })
+		runtimeCount := len(info.Libraries) - linkedCount
+
+		fmt.Fprintf(writer, "PID: %6d | UID: %3d | Risk: %5.1f (surface %4.1f + lang %4.1f + priv %4.1f + exposure %4.1f) | Size: %3.1f/%3.1f MB | File: %3.1f/%3.1f MB | Libs: %d linked/%d runtime | Name: %s | Lang: %s | Memory Safety: %s | Hardening: %s | Priv: %s | Listening: %s | Container: %s | Executable Path: %s \n",
+			info.Pid, info.UserId,
+			info.RiskScore.Total, info.RiskScore.AttackSurface, info.RiskScore.Language,
+			info.RiskScore.Privilege, info.RiskScore.Exposure,
+			float64(info.ExecutableSizeInBytes)/1024/1024,
+			float64(info.LibrariesSizeInBytes)/1024/1024,
+			float64(info.ExecutableFileSizeInBytes)/1024/1024,
+			float64(info.LibrariesFileSizeInBytes)/1024/1024,
+			linkedCount, runtimeCount,
+			displayedName, displayedLanguage, info.MemorySafety.Class, displayedHardening, describePrivilege(info), displayedListeningSockets, info.Container, info.ExecutablePath)
+
+		if details {
+			fmt.Fprintf(writer, "memory safety: %s (%s)\n", info.MemorySafety.Class, strings.Join(info.MemorySafety.Reasons, "; "))
+			if info.GoBuildInfo != nil {
+				printGoBuildInfo(writer, *info.GoBuildInfo)
+			}
+			if info.RustBuildInfo != nil {
+				printRustBuildInfo(writer, *info.RustBuildInfo)
+			}
+			if info.PythonBundle != nil {
+				printPythonBundle(writer, *info.PythonBundle)
+			}
+			if info.JavaScriptBundle != nil {
+				runtimeVersion := info.JavaScriptBundle.RuntimeVersion
+				if runtimeVersion == "" {
+					runtimeVersion = "unknown"
+				}
+				fmt.Fprintf(writer, "javascript bundle: %s, runtime %s, %s payload\n",
+					info.JavaScriptBundle.Packager, runtimeVersion,
+					bytefmt.ByteSize(uint64(info.JavaScriptBundle.PayloadSizeInBytes)))
+			}
+			if info.DotNetAssembly != nil {
+				printDotNetAssembly(writer, *info.DotNetAssembly)
+			}
+			if info.InterpretedApplication != nil {
+				printInterpretedApplication(writer, *info.InterpretedApplication)
+			}
+			printLibraryTree(writer, info)
+		}
+	}
+	printTextHostSummary(writer, calculateHostSummary(processInfos))
+}
+
+// describes the privilege class of a process with its capabilities and restrictions, e.g.
+// capabilities cap_net_bind_service (seccomp filter, no_new_privs)
+func describePrivilege(info ProcessInfo) string {
+	description := info.Privilege
+	if info.Status == nil {
+		return description
+	}
+	if info.Privilege == privilegeAdminCapabilities || info.Privilege == privilegeCapabilities {
+		description += " " + strings.Join(getCapabilityNames(info.Status.EffectiveCapabilities), ",")
+	}
+	var restrictions []string
+	if info.Status.Seccomp != "disabled" {
+		restrictions = append(restrictions, "seccomp "+info.Status.Seccomp)
+	}
+	if info.Status.NoNewPrivs {
+		restrictions = append(restrictions, "no_new_privs")
+	}
+	if len(restrictions) > 0 {
+		description += " (" + strings.Join(restrictions, ", ") + ")"
+	}
+	return description
+}
+
+// writes the toolchain, main module and build settings of a Go binary, e.g.
+// go build info: go1.22.1, github.com/example/app@v1.2.0, 12 dependencies, CGO_ENABLED=1, -trimpath, vcs.revision=3f2a...
+func printGoBuildInfo(writer io.Writer, goBuildInfo GoBuildInfo) {
+	fields := []string{goBuildInfo.GoVersion}
+	if goBuildInfo.MainModule.Path != "" {
+		// binaries built outside of a module have no main module
+		fields = append(fields, goBuildInfo.MainModule.Path+"@"+goBuildInfo.MainModule.Version)
+	}
+	fields = append(fields, fmt.Sprintf("%d dependencies", len(goBuildInfo.Dependencies)))
+	if goBuildInfo.CgoEnabled {
+		fields = append(fields, "CGO_ENABLED=1")
+	} else {
+		fields = append(fields, "CGO_ENABLED=0")
+	}
+	if goBuildInfo.Trimpath {
+		fields = append(fields, "-trimpath")
+	}
+	if goBuildInfo.VcsRevision != "" {
+		fields = append(fields, "vcs.revision="+goBuildInfo.VcsRevision)
+	}
+	fmt.Fprintf(writer, "go build info: %s\n", strings.Join(fields, ", "))
+}
+
+// writes the compiler and crates of a Rust binary, e.g.
+// rust build info: rustc 1.75.0 (82e1608df 2023-12-21), 42 crates (cargo-auditable)
+func printRustBuildInfo(writer io.Writer, rustBuildInfo RustBuildInfo) {
+	var fields []string
+	switch {
+	case rustBuildInfo.RustcVersion != "":
+		fields = append(fields, "rustc "+rustBuildInfo.RustcVersion)
+	case rustBuildInfo.RustcCommitHash != "":
+		fields = append(fields, "rustc commit "+rustBuildInfo.RustcCommitHash)
+	}
+	if rustBuildInfo.Auditable {
+		fields = append(fields, fmt.Sprintf("%d crates (cargo-auditable)", len(rustBuildInfo.Crates)))
+	} else {
+		fields = append(fields, "crates unknown, not built with cargo-auditable")
+	}
+	fmt.Fprintf(writer, "rust build info: %s\n", strings.Join(fields, ", "))
+}
+
+// writes the target framework and referenced assemblies of a .NET assembly, e.g.
+// dotnet assembly: app 1.0.0.0, .NETCoreApp,Version=v8.0, runtime v4.0.30319, IL only, references: System.Runtime 8.0.0.0, System.Console 8.0.0.0
+func printDotNetAssembly(writer io.Writer, assembly DotNetAssembly) {
+	fields := []string{strings.TrimSpace(assembly.Name + " " + assembly.Version)}
+	if assembly.TargetFramework != "" {
+		fields = append(fields, assembly.TargetFramework)
+	}
+	fields = append(fields, "runtime "+assembly.RuntimeVersion)
+	switch {
+	case assembly.ReadyToRun:
+		fields = append(fields, "ReadyToRun")
+	case assembly.ILOnly:
+		fields = append(fields, "IL only")
+	default:
+		fields = append(fields, "mixed-mode")
+	}
+	var references []string
+	for _, reference := range assembly.References {
+		references = append(references, reference.Name+" "+reference.Version)
+	}
+	if len(references) > 0 {
+		fields = append(fields, "references: "+strings.Join(references, ", "))
+	}
+	fmt.Fprintf(writer, "dotnet assembly: %s\n", strings.Join(fields, ", "))
+}
+
+// writes the application of an interpreter process, its code is part of the library tree, e.g.
+// interpreted application: python, /srv/app/main.py
+func printInterpretedApplication(writer io.Writer, application InterpretedApplication) {
+	fields := []string{application.Interpreter}
+	switch {
+	case application.Path != "":
+		fields = append(fields, application.Path)
+	case application.Module != "":
+		fields = append(fields, "module "+application.Module)
+	case application.InlineCode:
+		fields = append(fields, "inline code")
+	default:
+		fields = append(fields, "interactive")
+	}
+	if len(application.ClassPath) > 0 {
+		fields = append(fields, "class path "+strings.Join(application.ClassPath, string(filepath.ListSeparator)))
+	}
+	fmt.Fprintf(writer, "interpreted application: %s\n", strings.Join(fields, ", "))
+}
+
+// writes the packager and bundled code of a frozen Python application, e.g.
+// python bundle: PyInstaller, Python 3.11 (libpython3.11.so.1.0), 214 modules, 12 extension modules, scripts: app
+func printPythonBundle(writer io.Writer, bundle PythonBundle) {
+	fields := []string{bundle.Packager}
+	if bundle.PythonVersion != "" {
+		python := "Python " + bundle.PythonVersion
+		if bundle.PythonLibrary != "" {
+			python += " (" + bundle.PythonLibrary + ")"
+		}
+		fields = append(fields, python)
+	}
+	fields = append(fields,
+		fmt.Sprintf("%d modules", len(bundle.Modules)),
+		fmt.Sprintf("%d extension modules", len(bundle.ExtensionModules)))
+	if len(bundle.Scripts) > 0 {
+		fields = append(fields, "scripts: "+strings.Join(bundle.Scripts, " "))
+	}
+	fmt.Fprintf(writer, "python bundle: %s\n", strings.Join(fields, ", "))
+}
+
+// writes the executable and its libraries as tree, sorted by the size of their executable code, e.g.
+// /usr/bin/python3.11 (6.5M code, 6.6M file, PT_LOAD PF_X), embedded scripts are listed as payload
+// ├── /usr/lib/x86_64-linux-gnu/libc.so.6 (1.3M code, 1.8M file, PT_LOAD PF_X, linked)
+// └── /usr/lib/python3.11/lib-dynload/_ssl.cpython-311-x86_64-linux-gnu.so (68K code, 181K file, PT_LOAD PF_X, runtime)
+func printLibraryTree(writer io.Writer, info ProcessInfo) {
+	payload := ""
+	if info.ExecutablePayloadSizeInBytes > 0 {
+		payload = fmt.Sprintf(", %s payload", bytefmt.ByteSize(uint64(info.ExecutablePayloadSizeInBytes)))
+	}
+	fmt.Fprintf(writer, "%s (%s code, %s file, %s%s)\n",
+		info.ExecutablePath,
+		bytefmt.ByteSize(uint64(info.ExecutableSizeInBytes)),
+		bytefmt.ByteSize(uint64(info.ExecutableFileSizeInBytes)),
+		info.ExecutableSizeMethod, payload)
+
+	var sortedLibraries []LibraryInfo
+	From(info.Libraries).
+		OrderByDescendingT(func(l LibraryInfo) int64 {
+			return l.SizeInBytes
+		}).
+		ToSlice(&sortedLibraries)
+
+	for i, library := range sortedLibraries {
+		branch := "├──"
+		if i == len(sortedLibraries)-1 {
+			branch = "└──"
+		}
+		sizeMethod := library.SizeMethod
+		if sizeMethod == "" {
+			sizeMethod = "not measured"
+		}
+		fmt.Fprintf(writer, "%s %s (%s code, %s file, %s, %s)\n",
+			branch, library.Path,
+			bytefmt.ByteSize(uint64(library.SizeInBytes)),
+			bytefmt.ByteSize(uint64(library.FileSizeInBytes)),
+			sizeMethod, library.Origin)
+	}
+	fmt.Fprintln(writer)
+}
+
+// writes the report as JSON document, see Report
+func printJsonReport(writer io.Writer, processInfos []ProcessInfo) error {
+	// emit empty lists instead of null, so consumers don't have to handle both
+	for i := range processInfos {
+		if processInfos[i].LanguageEvidence == nil {
+			processInfos[i].LanguageEvidence = []string{}
+		}
+		if processInfos[i].WeightedLanguageEvidence == nil {
+			processInfos[i].WeightedLanguageEvidence = []LanguageEvidence{}
+		}
+		if processInfos[i].MemorySafety.Reasons == nil {
+			processInfos[i].MemorySafety.Reasons = []string{}
+		}
+		if processInfos[i].Libraries == nil {
+			processInfos[i].Libraries = []LibraryInfo{}
+		}
+		if processInfos[i].MemoryMappings == nil {
+			processInfos[i].MemoryMappings = []MemoryMapping{}
+		}
+		if processInfos[i].ListeningSockets == nil {
+			processInfos[i].ListeningSockets = []ListeningSocket{}
+		}
+		if processInfos[i].UnixSockets == nil {
+			processInfos[i].UnixSockets = []UnixSocket{}
+		}
+	}
+
+	summary := calculateHostSummary(processInfos)
+	if summary.LibrariesOfPrivilegedProcesses == nil {
+		summary.LibrariesOfPrivilegedProcesses = []PrivilegedLibrary{}
+	}
+	if summary.ProcessContributions == nil {
+		summary.ProcessContributions = []ProcessContribution{}
+	}
+
+	report := Report{
+		SchemaVersion: reportSchemaVersion,
+		GeneratedAt:   time.Now().UTC(),
+		Processes:     processInfos,
+		Summary:       summary,
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+)
+
+// RiskWeights configures how much each factor contributes to the risk exposure score, the score
+// of a process with the highest rating in all factors is the sum of all weights
+type RiskWeights struct {
+	AttackSurface float64 `json:"attack_surface"`
+	Language      float64 `json:"language"`
+	Privilege     float64 `json:"privilege"`
+	Exposure      float64 `json:"exposure"`
+	// attack-surface in bytes that gets the full weight, below that the rating grows logarithmically
+	AttackSurfaceCeilingInBytes int64 `json:"attack_surface_ceiling_in_bytes"`
+}
+
+// RiskScore is the risk exposure score of a process with the contribution of each factor
+type RiskScore struct {
+	Total         float64 `json:"total"`
+	AttackSurface float64 `json:"attack_surface"`
+	Language      float64 `json:"language"`
+	Privilege     float64 `json:"privilege"`
+	Exposure      float64 `json:"exposure"`
+}
+
+// default weights add up to a maximum score of 100
+var defaultRiskWeights = RiskWeights{
+	AttackSurface:               30,
+	Language:                    25,
+	Privilege:                   25,
+	Exposure:                    20,
+	AttackSurfaceCeilingInBytes: 1024 * 1024 * 1024,
+}
+
+// loads risk weights from a JSON file, weights missing in the file keep their default value
+func loadRiskWeights(path string) (RiskWeights, error) {
+	weights := defaultRiskWeights
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return weights, err
+	}
+	err = json.Unmarshal(data, &weights)
+	return weights, err
+}
+
+// calculates the risk exposure score of a process, each factor is rated between 0 and 1 and
+// contributes with its weight to the total score
+func calculateRiskScore(info ProcessInfo, weights RiskWeights) RiskScore {
+	score := RiskScore{
+		AttackSurface: weights.AttackSurface * rateAttackSurface(info, weights.AttackSurfaceCeilingInBytes),
+		Language:      weights.Language * rateLanguage(info),
+		Privilege:     weights.Privilege * ratePrivilege(info),
+		Exposure:      weights.Exposure * rateExposure(info),
+	}
+	score.Total = score.AttackSurface + score.Language + score.Privilege + score.Exposure
+	return score
+}
+
+// the more code is loaded the more bugs it contains, but doubling the code doesn't double the risk
+func rateAttackSurface(info ProcessInfo, ceilingInBytes int64) float64 {
+	sizeInMB := float64(info.ExecutableSizeInBytes+info.ExecutablePayloadSizeInBytes+info.LibrariesSizeInBytes) / 1024 / 1024
+	ceilingInMB := float64(ceilingInBytes) / 1024 / 1024
+	if ceilingInMB <= 0 {
+		return 0
+	}
+	return math.Min(1, math.Log1p(sizeInMB)/math.Log1p(ceilingInMB))
+}
+
+// memory-unsafe languages are prone to memory corruption bugs like buffer overflows or use-after-free
+func rateLanguage(info ProcessInfo) float64 {
+	switch info.MemorySafety.Class {
+	case memoryUnsafe:
+		return 1
+	case memoryMixed:
+		// a memory corruption bug in the unsafe part compromises the whole process
+		return 0.5
+	case memorySafe:
+		return 0
+	default:
+		// most binaries we can't identify are stripped C/C++ binaries
+		return 0.75
+	}
+}
+
+// privilege classes of a process, from most to least privileged
+const (
+	privilegeRoot              = "root"               // effective user id 0 that has or can gain CAP_SYS_ADMIN
+	privilegeSetuid            = "setuid"             // setuid executable of a binary that is not running
+	privilegeRegainableRoot    = "regainable root"    // real or saved user id 0, the process can switch back to root
+	privilegeRestrictedRoot    = "restricted root"    // user id 0 that can't gain CAP_SYS_ADMIN, e.g. in a container
+	privilegeAdminCapabilities = "admin capabilities" // capabilities equivalent to root, e.g. CAP_SYS_ADMIN or CAP_DAC_OVERRIDE
+	privilegeCapabilities      = "capabilities"       // other capabilities, e.g. CAP_NET_BIND_SERVICE
+	privilegeUnprivileged      = "unprivileged"
+)
+
+// classifies the privileges of a process by its credentials and capabilities, binaries that are
+// not running are classified by their user id and setuid bit only
+func classifyPrivilege(info ProcessInfo) string {
+	status := info.Status
+	if status == nil {
+		switch {
+		case info.UserId == 0:
+			return privilegeRoot
+		case info.IsSetuid:
+			// setuid executables run with the privileges of their owner, which is root for nearly all of them
+			return privilegeSetuid
+		default:
+			return privilegeUnprivileged
+		}
+	}
+
+	// permitted capabilities can be raised to effective ones at any time, a process with user id 0
+	// gets the bounding set on execve unless NoNewPrivs limits it to the permitted set
+	capabilities := status.EffectiveCapabilities | status.PermittedCapabilities
+	rootCapabilities := capabilities
+	if !status.NoNewPrivs {
+		rootCapabilities |= status.BoundingCapabilities
+	}
+	switch {
+	case status.Uids[1] == 0 && rootCapabilities&(1<<capSysAdmin) != 0:
+		return privilegeRoot
+	case status.Uids[1] == 0:
+		return privilegeRestrictedRoot
+	case (status.Uids[0] == 0 || status.Uids[2] == 0) && rootCapabilities&(1<<capSysAdmin) != 0:
+		return privilegeRegainableRoot
+	case status.Uids[0] == 0 || status.Uids[2] == 0:
+		return privilegeRestrictedRoot
+	case capabilities&adminCapabilities != 0:
+		return privilegeAdminCapabilities
+	case capabilities != 0:
+		return privilegeCapabilities
+	default:
+		return privilegeUnprivileged
+	}
+}
+
+// a compromised privileged process exposes all data and the whole system
+func ratePrivilege(info ProcessInfo) float64 {
+	var rating float64
+	switch classifyPrivilege(info) {
+	case privilegeRoot, privilegeSetuid:
+		rating = 1
+	case privilegeRegainableRoot:
+		rating = 0.9
+	case privilegeRestrictedRoot:
+		rating = 0.8
+	case privilegeAdminCapabilities:
+		rating = 0.75
+	case privilegeCapabilities:
+		rating = 0.4
+	default:
+		return 0
+	}
+	if info.Status != nil && info.Status.Seccomp != "disabled" {
+		// seccomp limits the system calls an attacker can use after taking over the process
+		rating *= 0.75
+	}
+	if info.Status != nil && info.Status.NoNewPrivs {
+		// NoNewPrivs keeps an attacker from gaining privileges with setuid or file capabilities executables
+		rating *= 0.9
+	}
+	return rating
+}
+
+// reports if a process runs with any elevated privileges
+func isPrivilegedProcess(info ProcessInfo) bool {
+	return ratePrivilege(info) > 0
+}
+
+// processes listening on the network can be attacked remotely, loopback and Unix sockets only from
+// the host itself
+func rateExposure(info ProcessInfo) float64 {
+	rating := 0.0
+	for _, socket := range info.UnixSockets {
+		if socket.IsWorldWritable() {
+			rating = math.Max(rating, 0.5)
+		} else {
+			rating = math.Max(rating, 0.25)
+		}
+	}
+	for _, socket := range info.ListeningSockets {
+		if socket.IsLoopback() {
+			rating = math.Max(rating, 0.5)
+		} else {
+			rating = 1
+		}
+	}
+	return rating
+}
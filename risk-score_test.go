@@ -0,0 +1,77 @@
+package main
+
+import "testing"
+
+func TestClassifyPrivilege(t *testing.T) {
+	const (
+		allCapabilities    uint64 = 1<<41 - 1
+		dockerCapabilities uint64 = 0x00000000a80425fb // default capabilities of a docker container
+		netBindService     uint64 = 1 << 10
+		sysAdmin           uint64 = 1 << capSysAdmin
+		withoutSysAdmin           = allCapabilities &^ sysAdmin
+		user                      = 1000
+		root                      = 0
+	)
+	tests := []struct {
+		name       string
+		uids       [4]int
+		effective  uint64
+		permitted  uint64
+		bounding   uint64
+		noNewPrivs bool
+		want       string
+	}{
+		{"root", [4]int{root, root, root, root}, allCapabilities, allCapabilities, allCapabilities, false, privilegeRoot},
+		{"container root", [4]int{root, root, root, root}, dockerCapabilities, dockerCapabilities, dockerCapabilities, false, privilegeRestrictedRoot},
+		{"root with dropped capabilities", [4]int{root, root, root, root}, 0, 0, allCapabilities, false, privilegeRoot},
+		{"root with dropped capabilities and NoNewPrivs", [4]int{root, root, root, root}, 0, 0, allCapabilities, true, privilegeRestrictedRoot},
+		{"root with CAP_SYS_ADMIN permitted", [4]int{root, root, root, root}, 0, sysAdmin, sysAdmin, true, privilegeRoot},
+		{"temporarily dropped root", [4]int{root, user, root, user}, 0, allCapabilities, allCapabilities, false, privilegeRegainableRoot},
+		{"temporarily dropped container root", [4]int{root, user, root, user}, 0, dockerCapabilities, dockerCapabilities, false, privilegeRestrictedRoot},
+		{"saved user id root", [4]int{user, user, root, user}, 0, 0, allCapabilities, false, privilegeRegainableRoot},
+		{"CAP_SYS_ADMIN", [4]int{user, user, user, user}, sysAdmin, sysAdmin, allCapabilities, false, privilegeAdminCapabilities},
+		{"permitted CAP_SYS_ADMIN", [4]int{user, user, user, user}, 0, sysAdmin, allCapabilities, false, privilegeAdminCapabilities},
+		{"CAP_NET_BIND_SERVICE", [4]int{user, user, user, user}, netBindService, netBindService, allCapabilities, false, privilegeCapabilities},
+		{"bounding set only", [4]int{user, user, user, user}, 0, 0, allCapabilities, false, privilegeUnprivileged},
+		{"user", [4]int{user, user, user, user}, 0, 0, withoutSysAdmin, false, privilegeUnprivileged},
+	}
+	for _, test := range tests {
+		info := ProcessInfo{Status: &ProcessStatus{
+			Uids:                  test.uids,
+			EffectiveCapabilities: test.effective,
+			PermittedCapabilities: test.permitted,
+			BoundingCapabilities:  test.bounding,
+			Seccomp:               "disabled",
+			NoNewPrivs:            test.noNewPrivs,
+		}}
+		if got := classifyPrivilege(info); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRatePrivilege(t *testing.T) {
+	root := ProcessStatus{Uids: [4]int{0, 0, 0, 0}, EffectiveCapabilities: 1 << capSysAdmin, Seccomp: "disabled"}
+	rootWithSeccomp := root
+	rootWithSeccomp.Seccomp = "filter"
+	rootWithNoNewPrivs := root
+	rootWithNoNewPrivs.NoNewPrivs = true
+	user := ProcessStatus{Uids: [4]int{1000, 1000, 1000, 1000}, Seccomp: "disabled", NoNewPrivs: true}
+
+	tests := []struct {
+		name string
+		info ProcessInfo
+		want float64
+	}{
+		{"root", ProcessInfo{Status: &root}, 1},
+		{"root with seccomp", ProcessInfo{Status: &rootWithSeccomp}, 0.75},
+		{"root with NoNewPrivs", ProcessInfo{Status: &rootWithNoNewPrivs}, 0.9},
+		{"user", ProcessInfo{Status: &user}, 0},
+		{"setuid binary", ProcessInfo{UserId: 1000, IsSetuid: true}, 1},
+	}
+	for _, test := range tests {
+		if got := ratePrivilege(test.info); got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
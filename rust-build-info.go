@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// RustBuildInfo holds the compiler and crates of a Rust binary, the crates are only known for binaries
+// built with cargo-auditable, which embeds them in a .dep-v0 section
+type RustBuildInfo struct {
+	RustcVersion    string      `json:"rustc_version"`     // e.g. 1.75.0 (82e1608df 2023-12-21), empty if not found
+	RustcCommitHash string      `json:"rustc_commit_hash"` // commit of the standard library, from its /rustc/<hash>/ source paths
+	Auditable       bool        `json:"auditable"`
+	Crates          []RustCrate `json:"crates"`
+}
+
+// RustCrate is a crate a Rust binary was built with
+type RustCrate struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Source  string `json:"source"` // crates.io, git, local or registry
+	Kind    string `json:"kind"`   // runtime or build, build dependencies like proc macros don't end up in the binary
+	Root    bool   `json:"root"`   // crate of the binary itself
+}
+
+// cargo-auditable dependency info, see https://github.com/rust-secure-code/cargo-auditable
+type rustAuditableInfo struct {
+	Packages []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+		Source  string `json:"source"`
+		Kind    string `json:"kind"` // runtime if missing
+		Root    bool   `json:"root"`
+	} `json:"packages"`
+}
+
+// the dependency info is tiny, the limit only protects against zip bombs
+const maxRustAuditableInfoSize = 16 * 1024 * 1024
+
+var rustcVersionPattern = regexp.MustCompile(`rustc version (\d+\.\d+\.\d+[-.\w]* \([0-9a-f]+ \d{4}-\d{2}-\d{2}\))`)
+var rustcCommitHashPattern = regexp.MustCompile(`/rustc/([0-9a-f]{40})/`)
+
+// reads the Rust build info of an ELF binary, the rustc version is in the .comment section
+func getElfRustBuildInfo(f *elf.File) *RustBuildInfo {
+	return parseRustBuildInfo(getElfSectionData(f, ".dep-v0"), getElfSectionData(f, ".comment"), getElfSectionData(f, ".rodata"))
+}
+
+// reads the Rust build info of a PE binary, there is no .comment section, so the rustc version is
+// searched in the read-only data like the standard library paths
+func getPERustBuildInfo(f *pe.File) *RustBuildInfo {
+	var depSection, rodataSection []byte
+	if section := f.Section(".dep-v0"); section != nil {
+		depSection, _ = section.Data()
+	}
+	if section := f.Section(".rdata"); section != nil {
+		rodataSection, _ = section.Data()
+	}
+	return parseRustBuildInfo(depSection, rodataSection, rodataSection)
+}
+
+// reads the Rust build info of a Mach-O binary, cargo-auditable puts the .dep-v0 section into the
+// __DATA segment and the rustc version is searched in the read-only data
+func getMachORustBuildInfo(f *macho.File) *RustBuildInfo {
+	depSection := getMachOSectionData(f, "__DATA", ".dep-v0")
+	rodataSection := append(getMachOSectionData(f, "__TEXT", "__const"), getMachOSectionData(f, "__DATA_CONST", "__const")...)
+	return parseRustBuildInfo(depSection, rodataSection, rodataSection)
+}
+
+// records the Rust build info of a binary with the evidence it gives for Rust, the rustc version is
+// only emitted by rustc while the standard library paths can come from a Rust library linked into a
+// binary of another language
+func addRustBuildInfo(info BinaryLanguageInfo, rustBuildInfo *RustBuildInfo) BinaryLanguageInfo {
+	if rustBuildInfo == nil {
+		return info
+	}
+	info.RustBuildInfo = rustBuildInfo
+	if rustBuildInfo.Auditable {
+		info.addEvidence(evidenceStructural, fmt.Sprintf("Rust dependency info (cargo-auditable): %d crates", len(rustBuildInfo.Crates)), "Rust")
+	}
+	if rustBuildInfo.RustcVersion != "" {
+		info.addEvidence(evidenceStructural, "rustc version: "+rustBuildInfo.RustcVersion, "Rust")
+	}
+	if rustBuildInfo.RustcCommitHash != "" {
+		info.addEvidence(evidenceString, "Rust standard library path: /rustc/"+rustBuildInfo.RustcCommitHash+"/", "Rust")
+	}
+	return info
+}
+
+func getElfSectionData(f *elf.File, name string) []byte {
+	section := f.Section(name)
+	if section == nil || section.Type == elf.SHT_NOBITS {
+		return nil
+	}
+	data, _ := section.Data()
+	return data
+}
+
+// returns the data of a section of a segment, section names like __const are used by several segments
+func getMachOSectionData(f *macho.File, segment string, name string) []byte {
+	for _, section := range f.Sections {
+		if section.Seg == segment && section.Name == name {
+			data, _ := section.Data()
+			return data
+		}
+	}
+	return nil
+}
+
+// parses the cargo-auditable dependency info and the rustc version and commit hash, returns nil if
+// none of them was found
+func parseRustBuildInfo(depSection []byte, commentSection []byte, rodataSection []byte) *RustBuildInfo {
+	info := &RustBuildInfo{Crates: []RustCrate{}}
+	found := false
+
+	if depSection != nil {
+		crates, err := parseRustAuditableInfo(depSection)
+		if err == nil {
+			info.Auditable = true
+			info.Crates = crates
+			found = true
+		}
+	}
+	if match := rustcVersionPattern.FindSubmatch(commentSection); match != nil {
+		info.RustcVersion = string(match[1])
+		found = true
+	}
+	if match := rustcCommitHashPattern.FindSubmatch(rodataSection); match != nil {
+		info.RustcCommitHash = string(match[1])
+		found = true
+	}
+
+	if !found {
+		return nil
+	}
+	return info
+}
+
+// parses the zlib compressed JSON of a .dep-v0 section
+func parseRustAuditableInfo(data []byte) ([]RustCrate, error) {
+	reader, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress cargo-auditable info: %v", err)
+	}
+	defer reader.Close()
+	jsonData, err := io.ReadAll(io.LimitReader(reader, maxRustAuditableInfoSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress cargo-auditable info: %v", err)
+	}
+
+	var auditableInfo rustAuditableInfo
+	if err := json.Unmarshal(jsonData, &auditableInfo); err != nil {
+		return nil, fmt.Errorf("failed to parse cargo-auditable info: %v", err)
+	}
+	crates := []RustCrate{}
+	for _, p := range auditableInfo.Packages {
+		kind := p.Kind
+		if kind == "" {
+			kind = "runtime"
+		}
+		crates = append(crates, RustCrate{Name: p.Name, Version: p.Version, Source: p.Source, Kind: kind, Root: p.Root})
+	}
+	return crates, nil
+}
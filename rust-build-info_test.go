@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"debug/elf"
+	"debug/macho"
+	"debug/pe"
+	"reflect"
+	"testing"
+)
+
+// crates of testdata/language-corpus/src/rust-auditable/dep-v0.json
+var testRustCrates = []RustCrate{
+	{Name: "cc", Version: "1.0.83", Source: "crates.io", Kind: "build"},
+	{Name: "hello", Version: "0.1.0", Source: "local", Kind: "runtime", Root: true},
+	{Name: "itoa", Version: "1.0.11", Source: "crates.io", Kind: "runtime"},
+	{Name: "libc", Version: "0.2.153", Source: "crates.io", Kind: "runtime"},
+}
+
+func TestGetRustBuildInfo(t *testing.T) {
+	want := RustBuildInfo{
+		RustcVersion:    "1.90.0 (1159e78c4 2025-09-14)",
+		RustcCommitHash: "1159e78c4747b02ef996e55082b704c09b970588",
+		Auditable:       true,
+		Crates:          testRustCrates,
+	}
+	tests := []struct {
+		file string
+		read func(path string) (*RustBuildInfo, error)
+	}{
+		{"testdata/language-corpus/hello-rust-auditable", func(path string) (*RustBuildInfo, error) {
+			f, err := elf.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			return getElfRustBuildInfo(f), nil
+		}},
+		{"testdata/rust-build-info/hello-macho.o", func(path string) (*RustBuildInfo, error) {
+			f, err := macho.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			return getMachORustBuildInfo(f), nil
+		}},
+		{"testdata/rust-build-info/hello-coff.o", func(path string) (*RustBuildInfo, error) {
+			f, err := pe.Open(path)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			return getPERustBuildInfo(f), nil
+		}},
+	}
+	for _, test := range tests {
+		info, err := test.read(test.file)
+		if err != nil {
+			t.Fatalf("%s: %v", test.file, err)
+		}
+		if info == nil || !reflect.DeepEqual(*info, want) {
+			t.Errorf("%s: got %+v, want %+v", test.file, info, want)
+		}
+	}
+}
+
+func TestParseRustAuditableInfo(t *testing.T) {
+	compress := func(data string) []byte {
+		var buffer bytes.Buffer
+		writer := zlib.NewWriter(&buffer)
+		writer.Write([]byte(data))
+		writer.Close()
+		return buffer.Bytes()
+	}
+	valid := compress(`{"packages":[{"name":"hello","version":"0.1.0","source":"local","root":true},` +
+		`{"name":"cc","version":"1.0.83","source":"crates.io","kind":"build"}]}`)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    []RustCrate
+		wantErr bool
+	}{
+		{"valid", valid, []RustCrate{
+			{Name: "hello", Version: "0.1.0", Source: "local", Kind: "runtime", Root: true},
+			{Name: "cc", Version: "1.0.83", Source: "crates.io", Kind: "build"},
+		}, false},
+		{"no packages", compress(`{"packages":[]}`), []RustCrate{}, false},
+		{"empty", nil, nil, true},
+		{"not compressed", []byte(`{"packages":[]}`), nil, true},
+		{"truncated", valid[:len(valid)/2], nil, true},
+		{"broken checksum", append(append([]byte{}, valid[:len(valid)-1]...), valid[len(valid)-1]^0xff), nil, true},
+		{"not JSON", compress("packages"), nil, true},
+		{"wrong JSON types", compress(`{"packages":{"name":"hello"}}`), nil, true},
+	}
+	for _, test := range tests {
+		crates, err := parseRustAuditableInfo(test.data)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got error %v, want error %v", test.name, err, test.wantErr)
+			continue
+		}
+		if !test.wantErr && !reflect.DeepEqual(crates, test.want) {
+			t.Errorf("%s: got %+v, want %+v", test.name, crates, test.want)
+		}
+	}
+
+	// a broken section doesn't hide the rustc version
+	info := parseRustBuildInfo([]byte("broken"), []byte("rustc version 1.90.0 (1159e78c4 2025-09-14)"), nil)
+	if info == nil || info.Auditable || info.RustcVersion != "1.90.0 (1159e78c4 2025-09-14)" {
+		t.Errorf("broken .dep-v0 section: got %+v", info)
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v4/process" // to get process information
+)
+
+var (
+	gListeningSocketsByNetNamespace map[string]map[uint64]ListeningSocket
+)
+
+// ListeningSocket describes a socket a process accepts connections or datagrams on
+type ListeningSocket struct {
+	Protocol string `json:"protocol"` // tcp, tcp6, udp or udp6
+	Address  string `json:"address"`
+	Port     uint32 `json:"port"`
+	Inode    uint64 `json:"-"`
+}
+
+// IsLoopback reports if the socket is only reachable from the host itself
+func (s ListeningSocket) IsLoopback() bool {
+	ip := net.ParseIP(s.Address)
+	return ip != nil && ip.IsLoopback()
+}
+
+// IsWildcard reports if the socket is bound to all addresses of the host
+func (s ListeningSocket) IsWildcard() bool {
+	ip := net.ParseIP(s.Address)
+	return ip != nil && ip.IsUnspecified()
+}
+
+func (s ListeningSocket) String() string {
+	var scope string
+	switch {
+	case s.IsLoopback():
+		scope = " (loopback)"
+	case s.IsWildcard():
+		scope = " (wildcard)"
+	}
+	return fmt.Sprintf("%s %s%s", s.Protocol, net.JoinHostPort(s.Address, strconv.Itoa(int(s.Port))), scope)
+}
+
+// retrieves the TCP and UDP sockets a process is listening on
+func getListeningSockets(proc *process.Process) ([]ListeningSocket, error) {
+	if runtime.GOOS == "linux" {
+		return getListeningSocketsFromProcfs(proc.Pid)
+	}
+
+	connections, err := proc.Connections()
+	if err != nil {
+		return nil, err
+	}
+
+	var sockets []ListeningSocket
+	for _, connection := range connections {
+		var protocol string
+		switch {
+		case connection.Type == syscall.SOCK_STREAM && connection.Status == "LISTEN":
+			protocol = "tcp"
+		case connection.Type == syscall.SOCK_DGRAM && connection.Raddr.Port == 0:
+			// unconnected UDP sockets receive datagrams from anyone
+			protocol = "udp"
+		default:
+			continue
+		}
+		if connection.Family == syscall.AF_INET6 {
+			protocol += "6"
+		}
+		sockets = append(sockets, ListeningSocket{
+			Protocol: protocol,
+			Address:  connection.Laddr.IP,
+			Port:     connection.Laddr.Port,
+		})
+	}
+	return sockets, nil
+}
+
+// retrieves the listening sockets of a process by matching the socket inodes of its file descriptors
+// against the sockets of its network namespace (Linux only)
+func getListeningSocketsFromProcfs(pid int32) ([]ListeningSocket, error) {
+	// processes in containers have their own network namespace and thus their own sockets
+	netNamespace, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/net", pid))
+	if err != nil {
+		return nil, err
+	}
+	if gListeningSocketsByNetNamespace == nil {
+		gListeningSocketsByNetNamespace = map[string]map[uint64]ListeningSocket{}
+	}
+	socketsByInode, ok := gListeningSocketsByNetNamespace[netNamespace]
+	if !ok {
+		socketsByInode = map[uint64]ListeningSocket{}
+		for _, protocol := range []string{"tcp", "tcp6", "udp", "udp6"} {
+			file, err := os.Open(fmt.Sprintf("/proc/%d/net/%s", pid, protocol))
+			if err != nil {
+				// e.g. IPv6 is disabled
+				continue
+			}
+			sockets, err := parseProcNetSockets(file, protocol)
+			file.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse /proc/%d/net/%s: %v", pid, protocol, err)
+			}
+			for _, socket := range sockets {
+				socketsByInode[socket.Inode] = socket
+			}
+		}
+		gListeningSocketsByNetNamespace[netNamespace] = socketsByInode
+	}
+
+	inodes, err := getSocketInodes(pid)
+	if err != nil {
+		return nil, err
+	}
+	var sockets []ListeningSocket
+	seen := map[uint64]bool{}
+	for _, inode := range inodes {
+		// the same socket may be open in multiple file descriptors
+		if socket, ok := socketsByInode[inode]; ok && !seen[inode] {
+			seen[inode] = true
+			sockets = append(sockets, socket)
+		}
+	}
+	return sockets, nil
+}
+
+// parses the listening sockets of the /proc/net/{tcp,tcp6,udp,udp6} format, e.g.
+// sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+// 0: 00000000:0016 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 18653 1 ...
+func parseProcNetSockets(reader io.Reader, protocol string) ([]ListeningSocket, error) {
+	var sockets []ListeningSocket
+	scanner := bufio.NewScanner(reader)
+	// skip header line
+	scanner.Scan()
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		state := fields[3]
+		_, remotePort, err := parseProcNetAddress(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasPrefix(protocol, "tcp") && state == "0A":
+			// TCP_LISTEN
+		case strings.HasPrefix(protocol, "udp") && state == "07" && remotePort == 0:
+			// unconnected UDP sockets are in state TCP_CLOSE and receive datagrams from anyone
+		default:
+			continue
+		}
+
+		address, port, err := parseProcNetAddress(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		inode, err := strconv.ParseUint(fields[9], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode: %s", fields[9])
+		}
+		sockets = append(sockets, ListeningSocket{
+			Protocol: protocol,
+			Address:  address.String(),
+			Port:     port,
+			Inode:    inode,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sockets, nil
+}
+
+// parses an address of the /proc/net/{tcp,udp} format, the kernel prints the IP address as
+// 32-bit words in host byte order and the port in hex, e.g. 0100007F:0035 for 127.0.0.1:53
+func parseProcNetAddress(value string) (net.IP, uint32, error) {
+	hexAddress, hexPort, found := strings.Cut(value, ":")
+	if !found {
+		return nil, 0, fmt.Errorf("invalid address: %s", value)
+	}
+	words, err := hex.DecodeString(hexAddress)
+	if err != nil || (len(words) != net.IPv4len && len(words) != net.IPv6len) {
+		return nil, 0, fmt.Errorf("invalid address: %s", value)
+	}
+	port, err := strconv.ParseUint(hexPort, 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid port: %s", value)
+	}
+
+	ip := make(net.IP, len(words))
+	for i := 0; i < len(words); i += 4 {
+		binary.NativeEndian.PutUint32(ip[i:], binary.BigEndian.Uint32(words[i:]))
+	}
+	return ip, uint32(port), nil
+}
+
+// returns the inodes of all sockets a process has open
+func getSocketInodes(pid int32) ([]uint64, error) {
+	fdDirectory := fmt.Sprintf("/proc/%d/fd", pid)
+	entries, err := os.ReadDir(fdDirectory)
+	if err != nil {
+		return nil, err
+	}
+	var inodes []uint64
+	for _, entry := range entries {
+		target, err := os.Readlink(fdDirectory + "/" + entry.Name())
+		if err != nil {
+			// the file descriptor was closed in the meantime
+			continue
+		}
+		// socket file descriptors link to e.g. socket:[18653]
+		value, found := strings.CutPrefix(target, "socket:[")
+		if !found {
+			continue
+		}
+		inode, err := strconv.ParseUint(strings.TrimSuffix(value, "]"), 10, 64)
+		if err == nil {
+			inodes = append(inodes, inode)
+		}
+	}
+	return inodes, nil
+}
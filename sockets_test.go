@@ -0,0 +1,141 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the procfs fixtures were captured on x86_64, the kernel prints addresses in host byte order
+func skipOnBigEndian(t *testing.T) {
+	if binary.NativeEndian.Uint16([]byte{1, 0}) != 1 {
+		t.Skip("procfs fixtures are little-endian")
+	}
+}
+
+func TestParseProcNetSockets(t *testing.T) {
+	skipOnBigEndian(t)
+	tests := []struct {
+		protocol string
+		expected []ListeningSocket
+	}{
+		// established connections are skipped
+		{"tcp", []ListeningSocket{
+			{Protocol: "tcp", Address: "127.0.0.1", Port: 48271, Inode: 906},
+			{Protocol: "tcp", Address: "0.0.0.0", Port: 8080, Inode: 71376},
+			{Protocol: "tcp", Address: "0.0.0.0", Port: 2024, Inode: 662},
+			{Protocol: "tcp", Address: "127.0.0.1", Port: 5432, Inode: 71377},
+		}},
+		{"tcp6", []ListeningSocket{
+			{Protocol: "tcp6", Address: "::1", Port: 6379, Inode: 71379},
+			{Protocol: "tcp6", Address: "::", Port: 443, Inode: 71378},
+		}},
+		// connected UDP sockets only receive datagrams of their peer
+		{"udp", []ListeningSocket{
+			{Protocol: "udp", Address: "127.0.0.1", Port: 53, Inode: 71381},
+			{Protocol: "udp", Address: "0.0.0.0", Port: 5353, Inode: 71380},
+		}},
+		{"udp6", []ListeningSocket{
+			{Protocol: "udp6", Address: "::", Port: 546, Inode: 71382},
+		}},
+	}
+	for _, test := range tests {
+		file, err := os.Open("testdata/procfs/net/" + test.protocol)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sockets, err := parseProcNetSockets(file, test.protocol)
+		file.Close()
+		if err != nil {
+			t.Errorf("%s: %v", test.protocol, err)
+			continue
+		}
+		if !reflect.DeepEqual(sockets, test.expected) {
+			t.Errorf("%s: got %v, expected %v", test.protocol, sockets, test.expected)
+		}
+	}
+}
+
+func TestParseProcNetAddress(t *testing.T) {
+	skipOnBigEndian(t)
+	tests := []struct {
+		value      string
+		address    string
+		port       uint32
+		loopback   bool
+		wildcard   bool
+		shouldFail bool
+	}{
+		{value: "0100007F:0035", address: "127.0.0.1", port: 53, loopback: true},
+		{value: "00000000:1F90", address: "0.0.0.0", port: 8080, wildcard: true},
+		{value: "0A02000A:0016", address: "10.0.2.10", port: 22},
+		{value: "00000000000000000000000001000000:18EB", address: "::1", port: 6379, loopback: true},
+		{value: "00000000000000000000000000000000:01BB", address: "::", port: 443, wildcard: true},
+		{value: "B80D0120000000000000000001000000:0050", address: "2001:db8::1", port: 80},
+		{value: "0000000000000000FFFF00000100007F:0277", address: "127.0.0.1", port: 631, loopback: true},
+		{value: "0100007F", shouldFail: true},
+		{value: "0100007:0035", shouldFail: true},
+		{value: "0100007F00:0035", shouldFail: true},
+		{value: "0100007F:10000", shouldFail: true},
+		{value: "XX00007F:0035", shouldFail: true},
+	}
+	for _, test := range tests {
+		ip, port, err := parseProcNetAddress(test.value)
+		if test.shouldFail {
+			if err == nil {
+				t.Errorf("%s: no error", test.value)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.value, err)
+			continue
+		}
+		socket := ListeningSocket{Address: ip.String(), Port: port}
+		if socket.Address != test.address || socket.Port != test.port ||
+			socket.IsLoopback() != test.loopback || socket.IsWildcard() != test.wildcard {
+			t.Errorf("%s: got %s loopback %t wildcard %t", test.value, socket, socket.IsLoopback(), socket.IsWildcard())
+		}
+	}
+}
+
+func TestParseProcNetUnixSockets(t *testing.T) {
+	file, err := os.Open("testdata/procfs/net/unix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	sockets, err := parseProcNetUnixSockets(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// unnamed sockets, accepted connections and stream sockets that do not listen are skipped
+	expected := []UnixSocket{
+		{Path: "/run/dbus/system_bus_socket", Type: "stream", Inode: 13399},
+		{Path: "@/tmp/.X11-unix/X0", Type: "stream", Inode: 20433},
+		{Path: "@/containerd-shim/4f1c9a.sock@", Type: "stream", Inode: 20511},
+		{Path: "/run/systemd/journal/dev-log", Type: "dgram", Inode: 11982},
+		{Path: "/run/udev/control", Type: "seqpacket", Inode: 12077},
+		{Path: "/run/user/1000/My App/ipc  socket", Type: "stream", Inode: 31337},
+	}
+	if !reflect.DeepEqual(sockets, expected) {
+		t.Errorf("got %+v, expected %+v", sockets, expected)
+	}
+	for _, socket := range sockets {
+		if socket.IsAbstract() != strings.HasPrefix(socket.Path, "@/") || socket.IsAbstract() && !socket.IsWorldWritable() {
+			t.Errorf("%s: got abstract %t, world-writable %t", socket.Path, socket.IsAbstract(), socket.IsWorldWritable())
+		}
+	}
+
+	for _, broken := range []string{
+		"Num       RefCount Protocol Flags    Type St Inode Path\n00000000394c4970: 00000002 00000000 0001000X 0001 01 13399 /run/a.sock",
+		"Num       RefCount Protocol Flags    Type St Inode Path\n00000000394c4970: 00000002 00000000 00010000 0001 01 -1 /run/a.sock",
+	} {
+		if sockets, err := parseProcNetUnixSockets(strings.NewReader(broken)); err == nil {
+			t.Errorf("got %+v for a broken line", sockets)
+		}
+	}
+}
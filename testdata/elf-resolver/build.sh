@@ -0,0 +1,17 @@
+#!/bin/sh
+# rebuilds an executable with a DT_RPATH that loads a library with a DT_RUNPATH, which loads a library that
+# is only found through the DT_RPATH of the executable: exe -> libA.so -> libB.so -> libC.so
+set -e
+cd "$(dirname "$0")"
+
+build_dir=$(mktemp -d)
+echo 'void c(void) {}' > "$build_dir/c.c"
+echo 'void c(void); void b(void) { c(); }' > "$build_dir/b.c"
+echo 'void b(void); void a(void) { b(); }' > "$build_dir/a.c"
+echo 'void a(void); void _start(void) { a(); }' > "$build_dir/exe.c"
+
+cc -s -Wl,-z,noseparate-code -shared -nostdlib -fPIC -Wl,-soname,libC.so -o libC.so "$build_dir/c.c"
+cc -s -Wl,-z,noseparate-code -shared -nostdlib -fPIC -Wl,-soname,libB.so -o libB.so "$build_dir/b.c" -L. -lC
+cc -s -Wl,-z,noseparate-code -shared -nostdlib -fPIC -Wl,-soname,libA.so -Wl,--enable-new-dtags,-rpath,/app/runpath -o libA.so "$build_dir/a.c" -L. -lB
+cc -s -Wl,-z,noseparate-code -nostdlib -Wl,--disable-new-dtags,-rpath,/app/rpath -Wl,-rpath-link,. -o exe "$build_dir/exe.c" -L. -lA
+rm -rf "$build_dir"
@@ -0,0 +1,10 @@
+#!/bin/sh
+# rebuilds an executable with all exploit mitigations of gcc and binutils and one without them, both
+# without the C runtime start files, so the CET property note only depends on hello.c
+set -e
+cd "$(dirname "$0")"
+
+gcc -s -O2 -D_FORTIFY_SOURCE=2 -fstack-protector-all -fcf-protection=full -fPIE -pie -nostartfiles \
+	-Wl,-z,relro,-z,now,-z,noexecstack -o hardened hello.c
+gcc -s -O2 -fno-stack-protector -fcf-protection=none -no-pie -nostartfiles \
+	-Wl,-z,relro,-z,lazy,-z,execstack -o unhardened hello.c
@@ -0,0 +1,50 @@
+#!/bin/sh
+# rebuilds the fixtures of the language corpus from their sources, the binaries are checked in so
+# the corpus stays the same when compilers change, rebuild and update labels.json deliberately
+set -e
+cd "$(dirname "$0")"
+
+# builds a .NET project outside of the source tree and copies its assembly, e.g. build_dotnet csharp hello-csharp
+build_dotnet() {
+	build_dir=$(mktemp -d)
+	cp -r "src/dotnet/$1/." "$build_dir"
+	DOTNET_CLI_TELEMETRY_OPTOUT=1 DOTNET_NOLOGO=1 dotnet build -c Release -o "$build_dir/out" "$build_dir" >/dev/null
+	cp "$build_dir/out/$2.dll" "$2.dll"
+	rm -rf "$build_dir"
+}
+
+gcc -Os -o hello-c src/hello.c
+g++ -Os -o hello-cpp src/hello.cpp
+rustc -C opt-level=s -C strip=debuginfo -o hello-rust src/hello.rs
+rustc -C opt-level=s -C prefer-dynamic -o hello-rust-dynamic src/hello.rs
+# the zlib compressed dependency info in a .dep-v0 section, as cargo-auditable embeds it, is added with
+# objcopy to not depend on cargo-auditable and crates.io
+python3 -c 'import sys, zlib; sys.stdout.buffer.write(zlib.compress(sys.stdin.buffer.read().strip()))' \
+	< src/rust-auditable/dep-v0.json > dep-v0.z
+objcopy --add-section .dep-v0=dep-v0.z hello-rust hello-rust-auditable
+rm dep-v0.z
+(cd src/go && GO111MODULE=off CGO_ENABLED=0 go build -trimpath -ldflags="-s -w" -o ../../hello-go .)
+(cd src/go-cgo && GO111MODULE=off CGO_ENABLED=1 go build -trimpath -ldflags="-s -w" -o ../../hello-go-cgo .)
+# PyInstaller is only used if installed, otherwise bundle.py appends a CArchive in its format to hello-c
+if command -v pyinstaller >/dev/null; then
+	build_dir=$(mktemp -d)
+	pyinstaller --onefile --distpath "$build_dir" --workpath "$build_dir/build" --specpath "$build_dir" \
+		--name hello-pyinstaller src/python/hello.py >/dev/null
+	cp "$build_dir/hello-pyinstaller" hello-pyinstaller
+	rm -rf "$build_dir"
+else
+	python3 src/python/bundle.py pyinstaller hello-c src/python/hello.py hello-pyinstaller
+	chmod +x hello-pyinstaller
+fi
+python3 -m zipapp src/python/zipapp -p "/usr/bin/env python3" -o hello-zipapp
+build_dir=$(mktemp -d)
+cp src/javascript/hello.js "$build_dir"
+echo '{"main": "hello.js", "output": "sea-prep.blob", "disableExperimentalSEAWarning": true}' > "$build_dir/sea-config.json"
+(cd "$build_dir" && node --experimental-sea-config sea-config.json >/dev/null)
+python3 src/javascript/bundle.py node-sea hello-c "$build_dir/sea-prep.blob" hello-node-sea
+rm -rf "$build_dir"
+python3 src/javascript/bundle.py deno hello-c src/javascript/hello.js hello-deno
+python3 src/javascript/bundle.py bun hello-c src/javascript/hello.js hello-bun
+build_dotnet csharp hello-csharp
+build_dotnet fsharp hello-fsharp
+build_dotnet vbnet hello-vbnet
@@ -0,0 +1 @@
+System.Console.WriteLine("hello");
@@ -0,0 +1 @@
+printfn "hello"
@@ -0,0 +1,9 @@
+package main
+
+// #include <stdio.h>
+// static void hello(void) { puts("hello"); }
+import "C"
+
+func main() {
+	C.hello()
+}
@@ -0,0 +1,45 @@
+#!/usr/bin/env python3
+# embeds a script into a C executable the way Node.js single executable applications, deno compile
+# and bun build --compile do, so the corpus has small fixtures instead of copies of the runtimes,
+# the Node.js blob is prepared by node --experimental-sea-config, Deno and Bun are not needed:
+#   bundle.py node-sea <executable> <blob> <output>
+#   bundle.py deno <executable> <script> <output>
+#   bundle.py bun <executable> <script> <output>
+import os
+import struct
+import subprocess
+import sys
+import tempfile
+
+
+def node_sea(executable, blob, output):
+    # postject injects the blob as note, objcopy adds a note section instead of a segment
+    blob = open(blob, "rb").read()
+    name = b"NODE_SEA_BLOB\0"
+    note = struct.pack("<III", len(name), len(blob), 0)
+    note += name + b"\0" * (-len(name) % 4) + blob + b"\0" * (-len(blob) % 4)
+    with tempfile.NamedTemporaryFile() as f:
+        f.write(note)
+        f.flush()
+        subprocess.check_call(["objcopy", "--add-section", ".note.node_sea=" + f.name, executable, output])
+
+
+def deno(executable, script, output):
+    # payload followed by the trailer: magic, eszip and metadata offset
+    data = open(executable, "rb").read()
+    payload = b"eszip" + open(script, "rb").read()
+    metadata = b'{"argv":[]}'
+    trailer = b"d3n0l4nd" + struct.pack(">QQ", len(data), len(data) + len(payload))
+    with open(output, "wb") as f:
+        f.write(data + payload + metadata + trailer)
+
+
+def bun(executable, script, output):
+    # module graph followed by the trailer
+    with open(output, "wb") as f:
+        f.write(open(executable, "rb").read() + open(script, "rb").read() + b"\n---- Bun! ----\n")
+
+
+if __name__ == "__main__":
+    {"node-sea": node_sea, "deno": deno, "bun": bun}[sys.argv[1]](*sys.argv[2:])
+    os.chmod(sys.argv[4], 0o755)
@@ -0,0 +1,54 @@
+#!/usr/bin/env python3
+# appends a Python application to a C executable the way PyInstaller does, so the corpus has a
+# PyInstaller fixture without a PyInstaller installation:
+#   bundle.py pyinstaller <bootloader> <script> <output>
+import marshal
+import struct
+import sys
+import zlib
+
+COOKIE_MAGIC = b"MEI\014\013\012\013\016"
+
+
+def pyz_archive(modules):
+    # magic, bytecode magic and offset of the marshalled table of contents
+    data = b""
+    toc = []
+    header_size = 12
+    for name, source in modules.items():
+        code = zlib.compress(marshal.dumps(compile(source, name, "exec")))
+        toc.append((name, (0, header_size + len(data), len(code))))
+        data += code
+    return b"PYZ\0" + b"\0\0\0\0" + struct.pack("!i", header_size + len(data)) + data + marshal.dumps(toc)
+
+
+def toc_entry(offset, data, type_code, name):
+    name = name.encode() + b"\0"
+    name += b"\0" * (-(18 + len(name)) % 16)
+    return struct.pack("!iIIIBc", 18 + len(name), offset, len(data), len(data), 0, type_code) + name
+
+
+def pyinstaller(bootloader, script, output):
+    version = sys.version_info
+    entries = [
+        (b"s", "pyiboot01_bootstrap", b""),
+        (b"s", script.rsplit("/", 1)[-1].removesuffix(".py"), marshal.dumps(compile(open(script).read(), script, "exec"))),
+        (b"z", "PYZ-00.pyz", pyz_archive({"json": "", "json.decoder": "", "hello_helper": "x = 1"})),
+        (b"b", "_json.cpython-%d%d-x86_64-linux-gnu.so" % version[:2], b"\x7fELF"),
+    ]
+    package = b""
+    toc = b""
+    for type_code, name, data in entries:
+        toc += toc_entry(len(package), data, type_code, name)
+        package += data
+    toc_offset = len(package)
+    package += toc
+    library = ("libpython%d.%d.so.1.0" % version[:2]).encode()
+    cookie = struct.pack("!8sIIIi64s", COOKIE_MAGIC, len(package) + 88, toc_offset, len(toc),
+                         version[0] * 100 + version[1], library)
+    with open(output, "wb") as f:
+        f.write(open(bootloader, "rb").read() + package + cookie)
+
+
+if __name__ == "__main__":
+    {"pyinstaller": pyinstaller}[sys.argv[1]](*sys.argv[2:])
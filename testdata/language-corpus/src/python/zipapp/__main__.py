@@ -0,0 +1,3 @@
+import hello_helper
+
+hello_helper.hello()
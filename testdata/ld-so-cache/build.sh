@@ -0,0 +1,18 @@
+#!/bin/sh
+# rebuilds the ld.so.cache fixtures in all formats ldconfig(8) writes, from a root file system with
+# a library in a multiarch directory, one in an /etc/ld.so.conf directory and a library name in both
+set -e
+cd "$(dirname "$0")"
+
+root=$(mktemp -d)
+mkdir -p "$root/etc" "$root/usr/lib/x86_64-linux-gnu" "$root/opt/app/lib"
+echo 'int f(void) { return 1; }' > "$root/f.c"
+gcc -shared -fPIC -Wl,-soname,libfoo.so.1 -o "$root/usr/lib/x86_64-linux-gnu/libfoo.so.1.2.3" "$root/f.c"
+gcc -shared -fPIC -Wl,-soname,libfoo.so.1 -o "$root/opt/app/lib/libfoo.so.1.0.0" "$root/f.c"
+gcc -shared -fPIC -Wl,-soname,libbar.so.2 -o "$root/opt/app/lib/libbar.so.2.0" "$root/f.c"
+printf '/opt/app/lib\n/usr/lib/x86_64-linux-gnu\n' > "$root/etc/ld.so.conf"
+for format in new old compat; do
+	ldconfig -r "$root" -c $format -C /ld.so.cache -f /etc/ld.so.conf
+	cp "$root/ld.so.cache" "ld.so.cache-$format"
+done
+rm -rf "$root"
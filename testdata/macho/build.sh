@@ -0,0 +1,77 @@
+#!/bin/sh
+# rebuilds the Mach-O fixtures on any OS with LLVM, a .app bundle whose universal executable and
+# frameworks load their dylibs via @rpath, @executable_path and @loader_path, e.g.
+# LD64="rust-lld -flavor darwin" ./build.sh
+set -e
+cd "$(dirname "$0")"
+LD64=${LD64:-ld64.lld}
+LIPO=${LIPO:-llvm-lipo}
+
+build_dir=$(mktemp -d)
+# stub of libSystem to link against, it is resolved from the dyld shared cache on macOS
+cat > "$build_dir/libSystem.tbd" <<TBD
+--- !tapi-tbd
+tbd-version:     4
+targets:         [ x86_64-macos, arm64-macos ]
+install-name:    '/usr/lib/libSystem.B.dylib'
+current-version: 1351
+exports:
+  - targets:         [ x86_64-macos, arm64-macos ]
+    symbols:         [ dyld_stub_binder ]
+...
+TBD
+
+# assembles a function calling the given functions, e.g. assemble x86_64 main greet util
+assemble() {
+	arch=$1 name=$2
+	shift 2
+	{
+		echo "	.globl _$name"
+		echo "_$name:"
+		for callee in "$@"; do
+			if [ "$arch" = x86_64 ]; then echo "	call _$callee"; else echo "	bl _$callee"; fi
+		done
+		echo "	ret"
+	} > "$build_dir/$name-$arch.s"
+	llvm-mc -triple "$arch-apple-macos11" -filetype=obj -o "$build_dir/$name-$arch.o" "$build_dir/$name-$arch.s"
+}
+
+# links a dylib or an executable, e.g. link x86_64 out.dylib -dylib -install_name @rpath/out.dylib in.o
+link() {
+	arch=$1 output=$2
+	shift 2
+	$LD64 -arch "$arch" -platform_version macos 11.0 11.0 -o "$output" "$@" "$build_dir/libSystem.tbd"
+}
+
+rm -rf Hello.app
+contents=Hello.app/Contents
+framework=$contents/Frameworks/Greeting.framework
+mkdir -p "$contents/MacOS" "$framework/Versions/A/Libraries"
+ln -s A "$framework/Versions/Current"
+ln -s Versions/Current/Greeting "$framework/Greeting"
+
+for arch in x86_64 arm64; do
+	out=$build_dir/$arch
+	mkdir -p "$out"
+	assemble $arch deep
+	assemble $arch helper
+	assemble $arch missing
+	assemble $arch util
+	assemble $arch greet helper deep
+	assemble $arch main greet util missing
+	link $arch "$out/libdeep.dylib" -dylib -install_name @rpath/libdeep.dylib "$build_dir/deep-$arch.o"
+	link $arch "$out/libhelper.dylib" -dylib -install_name @loader_path/../../../libhelper.dylib "$build_dir/helper-$arch.o"
+	link $arch "$out/libmissing.dylib" -dylib -install_name @rpath/libmissing.dylib "$build_dir/missing-$arch.o"
+	link $arch "$out/libutil.dylib" -dylib -install_name @executable_path/../Frameworks/libutil.dylib "$build_dir/util-$arch.o"
+	link $arch "$out/Greeting" -dylib -install_name @rpath/Greeting.framework/Greeting -rpath @loader_path/Libraries \
+		"$build_dir/greet-$arch.o" "$out/libhelper.dylib" "$out/libdeep.dylib"
+	link $arch "$out/Hello" -rpath @executable_path/../Frameworks "$build_dir/main-$arch.o" "$out/Greeting" \
+		"$out/libutil.dylib" -weak_library "$out/libmissing.dylib"
+done
+
+# the executable is universal, the libraries are thin, libmissing.dylib is weak and not shipped
+$LIPO -create -output "$contents/MacOS/Hello" "$build_dir/x86_64/Hello" "$build_dir/arm64/Hello"
+cp "$build_dir/x86_64/Greeting" "$framework/Versions/A/Greeting"
+cp "$build_dir/x86_64/libdeep.dylib" "$framework/Versions/A/Libraries/libdeep.dylib"
+cp "$build_dir/x86_64/libhelper.dylib" "$build_dir/x86_64/libutil.dylib" "$contents/Frameworks/"
+rm -rf "$build_dir"
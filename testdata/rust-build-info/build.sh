@@ -0,0 +1,26 @@
+#!/bin/sh
+# rebuilds Mach-O and COFF objects with the sections Rust build info is read from on macOS and Windows: the
+# dependency info of cargo-auditable in .dep-v0 and the rustc version and standard library paths in the
+# read-only data
+set -e
+cd "$(dirname "$0")"
+
+build_dir=$(mktemp -d)
+python3 -c 'import sys, zlib; sys.stdout.buffer.write(zlib.compress(sys.stdin.buffer.read().strip()))' \
+	< ../language-corpus/src/rust-auditable/dep-v0.json > "$build_dir/dep-v0.z"
+
+# assembles an object with the sections, e.g. assemble x86_64-apple-macos11 __DATA,.dep-v0 __TEXT,__const hello.o
+assemble() {
+	{
+		echo "	.section $2"
+		echo "	.incbin \"$build_dir/dep-v0.z\""
+		echo "	.section $3"
+		echo '	.ascii "rustc version 1.90.0 (1159e78c4 2025-09-14)\0"'
+		echo '	.ascii "/rustc/1159e78c4747b02ef996e55082b704c09b970588/library/core/src/panicking.rs\0"'
+	} > "$build_dir/hello.s"
+	llvm-mc -triple "$1" -filetype=obj -o "$4" "$build_dir/hello.s"
+}
+
+assemble x86_64-apple-macos11 __DATA,.dep-v0 __TEXT,__const hello-macho.o
+assemble x86_64-pc-windows-msvc '".dep-v0","dr"' '.rdata,"dr"' hello-coff.o
+rm -rf "$build_dir"
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	gUnixSocketsByNetNamespace map[string]map[uint64]UnixSocket
+)
+
+// UnixSocket describes a Unix domain socket a process accepts connections or datagrams on
+type UnixSocket struct {
+	Path        string      `json:"path"`        // path of the socket file, abstract sockets start with @
+	Type        string      `json:"type"`        // stream, dgram or seqpacket
+	Inode       uint64      `json:"-"`           // inode of the socket, not of the socket file
+	Permissions os.FileMode `json:"permissions"` // permissions of the socket file, abstract sockets have none
+	OwnerUid    int         `json:"owner_uid"`   // owner of the socket file, -1 if unknown
+	OwnerGid    int         `json:"owner_gid"`   // group of the socket file, -1 if unknown
+}
+
+// IsAbstract reports if the socket lives in the abstract namespace instead of the file system
+func (s UnixSocket) IsAbstract() bool {
+	return strings.HasPrefix(s.Path, "@")
+}
+
+// IsWorldWritable reports if any local user can connect to the socket, abstract sockets have no
+// permissions at all and can be connected to by anyone in the same network namespace
+func (s UnixSocket) IsWorldWritable() bool {
+	return s.IsAbstract() || s.Permissions&0o002 != 0
+}
+
+func (s UnixSocket) String() string {
+	switch {
+	case s.IsAbstract():
+		return fmt.Sprintf("%s %s (abstract)", s.Type, s.Path)
+	case s.OwnerUid < 0:
+		return fmt.Sprintf("%s %s", s.Type, s.Path)
+	case s.IsWorldWritable():
+		return fmt.Sprintf("%s %s (%s %d:%d, world-writable)", s.Type, s.Path, s.Permissions, s.OwnerUid, s.OwnerGid)
+	default:
+		return fmt.Sprintf("%s %s (%s %d:%d)", s.Type, s.Path, s.Permissions, s.OwnerUid, s.OwnerGid)
+	}
+}
+
+// retrieves the Unix domain sockets a process is listening on (Linux only), their socket files are
+// looked up in the file system of the process, which is the one of its container
+func getUnixSockets(fsys FileSystem, pid int32) ([]UnixSocket, error) {
+	// abstract sockets are bound to the network namespace
+	netNamespace, err := os.Readlink(fmt.Sprintf("/proc/%d/ns/net", pid))
+	if err != nil {
+		return nil, err
+	}
+	if gUnixSocketsByNetNamespace == nil {
+		gUnixSocketsByNetNamespace = map[string]map[uint64]UnixSocket{}
+	}
+	socketsByInode, ok := gUnixSocketsByNetNamespace[netNamespace]
+	if !ok {
+		socketsByInode = map[uint64]UnixSocket{}
+		file, err := os.Open(fmt.Sprintf("/proc/%d/net/unix", pid))
+		if err != nil {
+			return nil, err
+		}
+		sockets, err := parseProcNetUnixSockets(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse /proc/%d/net/unix: %v", pid, err)
+		}
+		for _, socket := range sockets {
+			socketsByInode[socket.Inode] = socket
+		}
+		gUnixSocketsByNetNamespace[netNamespace] = socketsByInode
+	}
+
+	inodes, err := getSocketInodes(pid)
+	if err != nil {
+		return nil, err
+	}
+	var sockets []UnixSocket
+	seen := map[uint64]bool{}
+	for _, inode := range inodes {
+		socket, ok := socketsByInode[inode]
+		if !ok || seen[inode] {
+			continue
+		}
+		seen[inode] = true
+
+		socket.OwnerUid, socket.OwnerGid = -1, -1
+		if !socket.IsAbstract() {
+			// a root daemon with a world-writable socket is a local privilege escalation vector
+			if fileInfo, err := fsys.Stat(socket.Path); err == nil {
+				socket.Permissions = fileInfo.Mode().Perm()
+				if uid, gid, ok := getFileOwner(fileInfo); ok {
+					socket.OwnerUid, socket.OwnerGid = uid, gid
+				}
+			}
+		}
+		sockets = append(sockets, socket)
+	}
+	return sockets, nil
+}
+
+// parses the listening sockets of the /proc/net/unix format, e.g.
+// Num       RefCount Protocol Flags    Type St Inode Path
+// 00000000394c4970: 00000002 00000000 00010000 0001 01 13399 /run/dbus/system_bus_socket
+func parseProcNetUnixSockets(reader io.Reader) ([]UnixSocket, error) {
+	const acceptConnections = 0x10000 // __SO_ACCEPTCON
+
+	var sockets []UnixSocket
+	scanner := bufio.NewScanner(reader)
+	// skip header line
+	scanner.Scan()
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.Fields(line)
+		if len(fields) < 8 {
+			// unnamed sockets, e.g. created by socketpair(), are no entry-points
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flags: %s", fields[3])
+		}
+		inode, err := strconv.ParseUint(fields[6], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid inode: %s", fields[6])
+		}
+
+		var socketType string
+		switch fields[4] {
+		case "0001":
+			socketType = "stream"
+		case "0002":
+			socketType = "dgram"
+		case "0005":
+			socketType = "seqpacket"
+		default:
+			continue
+		}
+		// bound datagram sockets receive messages without listening, connection oriented sockets
+		// only accept connections once they listen
+		if socketType != "dgram" && flags&acceptConnections == 0 {
+			continue
+		}
+
+		// the path follows the inode after a single space and may contain any number of spaces itself
+		path := line
+		for i := 0; i < 7; i++ {
+			path = strings.TrimLeft(path, " ")
+			path = path[strings.IndexByte(path, ' ')+1:]
+		}
+
+		sockets = append(sockets, UnixSocket{
+			Path:  path,
+			Type:  socketType,
+			Inode: inode,
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sockets, nil
+}